@@ -7,13 +7,13 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/tidwall/gjson"
-	"github.com/tidwall/sjson"
 )
 
 // rootHandler 处理根路径 "/" 请求的函数。
@@ -31,44 +31,211 @@ func rootHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// taggedArrays 元素以 tag 字段标识的数组，路径中可以用标签代替下标，如 outbounds.hk-01.tls.server_name
+var taggedArrays = []string{"outbounds", "inbounds", "endpoints", "dns.servers", "route.rule_set"}
+
+// ruleArrays 规则数组，元素没有标签，在配置结构列表中以下标加摘要显示
+var ruleArrays = []string{"route.rules", "dns.rules"}
+
 // resolvePath 辅助函数（翻译官）
+// 把路径中带标签数组的标签翻译为下标，如 outbounds.hk-01.tls.server_name -> outbounds.1.tls.server_name。
+// 标签中的 . 可以转义也可以不转义；多个标签都能匹配时取最长的一个。找不到标签时原样返回，
+// 因此 route.rules.3 这样的下标路径也能直接使用。
 func resolvePath(content []byte, userPath string) string {
 	if userPath == "" {
 		return ""
 	}
-	parts := strings.SplitN(userPath, ".", 2)
-	if len(parts) != 2 {
-		return userPath
-	}
-	rootKey := parts[0]
-	tagOrKey := parts[1]
-
-	if rootKey == "outbounds" || rootKey == "inbounds" {
-		list := gjson.GetBytes(content, rootKey)
-		if list.IsArray() {
-			realIndex := -1
-			list.ForEach(func(key, value gjson.Result) bool {
-				if value.Get("tag").String() == tagOrKey {
-					realIndex = int(key.Int())
-					return false
+	for _, arrayPath := range taggedArrays {
+		if !strings.HasPrefix(userPath, arrayPath+".") {
+			continue
+		}
+		rest := userPath[len(arrayPath)+1:]
+		if segment, _, _ := strings.Cut(rest, "."); isIndexSegment(segment) {
+			// 纯数字总是下标，即使有元素的标签恰好是这个数字
+			return userPath
+		}
+		list := jsoncGet(content, arrayPath)
+		if !list.IsArray() {
+			return userPath
+		}
+		realIndex, matched := -1, 0
+		list.ForEach(func(key, value gjson.Result) bool {
+			tag := value.Get("tag").String()
+			for _, form := range []string{tag, gjson.Escape(tag)} {
+				if form != "" && len(form) > matched && (rest == form || strings.HasPrefix(rest, form+".")) {
+					realIndex, matched = int(key.Int()), len(form)
 				}
-				return true
-			})
-			if realIndex != -1 {
-				return fmt.Sprintf("%s.%d", rootKey, realIndex)
 			}
+			return true
+		})
+		if realIndex == -1 {
+			return userPath
 		}
+		return fmt.Sprintf("%s.%d%s", arrayPath, realIndex, rest[matched:])
 	}
 	return userPath
 }
 
+// isIndexSegment 判断路径片段是否为数组下标
+func isIndexSegment(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	for _, c := range segment {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleActionFields 规则中表示动作（而不是匹配条件）的字段，生成摘要时跳过
+var ruleActionFields = func() map[string]bool {
+	fields := map[string]bool{"type": true, "mode": true, "rules": true, "invert": true}
+	for _, sample := range []interface{}{RouteActionFields{}, DNSActionFields{}} {
+		for _, field := range schemaOf(sample).Fields {
+			fields[field.Name] = true
+		}
+	}
+	return fields
+}()
+
+// ruleSummary 生成路由规则或 DNS 规则的简短摘要，如 "domain_suffix: google.com → proxy"
+func ruleSummary(rule gjson.Result) string {
+	var conditions []string
+	if rule.Get("type").String() == "logical" {
+		conditions = append(conditions, fmt.Sprintf("%s(%d 条)", rule.Get("mode").String(), len(rule.Get("rules").Array())))
+	}
+	rule.ForEach(func(key, value gjson.Result) bool {
+		if ruleActionFields[key.Str] {
+			return true
+		}
+		text := value.String()
+		if value.IsArray() {
+			var items []string
+			for _, item := range value.Array() {
+				items = append(items, item.String())
+			}
+			text = strings.Join(items, ", ")
+			if len(items) > 2 {
+				text = fmt.Sprintf("%s, %s 等 %d 项", items[0], items[1], len(items))
+			}
+		}
+		conditions = append(conditions, key.Str+": "+text)
+		return true
+	})
+	summary := strings.Join(conditions, "; ")
+	if rule.Get("invert").Bool() {
+		summary = "非 " + summary
+	}
+	if summary == "" {
+		summary = "(任意)"
+	}
+	target := rule.Get("action").String()
+	if target == "" || target == "route" {
+		if target = rule.Get("outbound").String(); target == "" {
+			target = rule.Get("server").String()
+		}
+	}
+	if target != "" {
+		summary += " → " + target
+	}
+	return summary
+}
+
+// TopKeyItem 配置结构列表中的一项
+type TopKeyItem struct {
+	Label     string `json:"label"`                // 显示名称：键名、标签或规则摘要
+	Path      string `json:"path"`                 // 读取和保存片段使用的路径，带标签的元素使用标签
+	Depth     int    `json:"depth,omitempty"`      // 嵌套层级，数组元素比所在的数组深一层
+	Array     bool   `json:"array,omitempty"`      // 这一项是可以增删元素的数组，元素紧随其后
+	ArrayPath string `json:"array_path,omitempty"` // 数组元素所在的数组
+	Index     int    `json:"index,omitempty"`      // 数组元素的下标
+	Tag       string `json:"tag,omitempty"`        // 数组元素的标签
+	Type      string `json:"type"`                 // 值的类型，object 和 array 可以通过 /api/get_tree 继续展开
+	Length    int    `json:"length,omitempty"`     // 对象的键数或数组的元素个数
+}
+
+// elementItems 列出带标签数组或规则数组的元素。
+// 标签唯一时用标签寻址，否则（没有标签、标签重复或标签是纯数字）用下标寻址。
+func elementItems(list gjson.Result, arrayPath string, depth int) []TopKeyItem {
+	tagged := containsString(taggedArrays, arrayPath)
+	tagCount := make(map[string]int)
+	for _, elem := range list.Array() {
+		tagCount[elem.Get("tag").String()]++
+	}
+	var items []TopKeyItem
+	for i, elem := range list.Array() {
+		item := TopKeyItem{
+			Label:     strconv.Itoa(i),
+			Path:      joinJSONPath(arrayPath, strconv.Itoa(i)),
+			Depth:     depth,
+			ArrayPath: arrayPath,
+			Index:     i,
+			Type:      valueType(elem),
+			Length:    valueLength(elem),
+		}
+		if tagged {
+			if tag := elem.Get("tag").String(); tag != "" {
+				item.Label, item.Tag = tag, tag
+				if tagCount[tag] == 1 && !isIndexSegment(tag) {
+					item.Path = joinJSONPath(arrayPath, tag)
+				}
+			} else if typ := elem.Get("type").String(); typ != "" {
+				item.Label = fmt.Sprintf("%d · %s", i, typ)
+			}
+		} else if elem.IsObject() {
+			item.Label = fmt.Sprintf("%d · %s", i, ruleSummary(elem))
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// objectItems 列出对象的键，其中带标签数组和规则数组的元素紧随数组之后列出；
+// 包含这类数组的对象（多个顶层键的文件中的 dns、route）也会展开一层。
+func objectItems(object gjson.Result, parentPath string, depth int) []TopKeyItem {
+	var items []TopKeyItem
+	object.ForEach(func(key, value gjson.Result) bool {
+		itemPath := joinJSONPath(parentPath, key.Str)
+		item := TopKeyItem{Label: key.Str, Path: itemPath, Depth: depth, Type: valueType(value), Length: valueLength(value)}
+		switch {
+		case value.IsArray() && (containsString(taggedArrays, itemPath) || containsString(ruleArrays, itemPath)):
+			item.Array = true
+			items = append(items, item)
+			items = append(items, elementItems(value, itemPath, depth+1)...)
+		case value.IsObject() && containsArrayUnder(itemPath):
+			items = append(items, item)
+			items = append(items, objectItems(value, itemPath, depth+1)...)
+		default:
+			items = append(items, item)
+		}
+		return true
+	})
+	return items
+}
+
+// containsArrayUnder 判断 path 下是否有带标签数组或规则数组
+func containsArrayUnder(path string) bool {
+	for _, arrayPath := range append(append([]string{}, taggedArrays...), ruleArrays...) {
+		if strings.HasPrefix(arrayPath, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
 // GetTopKeysResponse ...
 type GetTopKeysResponse struct {
-	RootContextKey string   `json:"root_context_key,omitempty"`
-	Keys           []string `json:"keys"`
+	RootContextKey string       `json:"root_context_key,omitempty"`
+	Keys           []string     `json:"keys"`
+	IsArray        bool         `json:"is_array,omitempty"` // 根上下文是数组，Keys 与数组元素一一对应
+	Items          []TopKeyItem `json:"items"`              // 配置结构列表，包含可寻址数组的元素
 }
 
 // getTopKeysHandler ...
+// 文件只有一个顶层键时，列出它内部的键（或数组元素）；带标签数组（出站、入站、端点、DNS 服务器、规则集）
+// 的元素以标签列出，路由规则和 DNS 规则以下标加摘要列出。
 func getTopKeysHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
@@ -89,7 +256,7 @@ func getTopKeysHandler(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, fmt.Sprintf("无法读取文件 '%s': %v", filename, err), http.StatusInternalServerError)
 		return
 	}
-	result := gjson.ParseBytes(contentBytes)
+	result := jsoncParse(contentBytes)
 	if !result.IsObject() {
 		writeJSONError(w, "文件内容不是一个有效的JSON对象。", http.StatusBadRequest)
 		return
@@ -102,34 +269,43 @@ func getTopKeysHandler(w http.ResponseWriter, r *http.Request) {
 	response := GetTopKeysResponse{
 		RootContextKey: "",
 		Keys:           topLevelKeys,
+		Items:          objectItems(result, "", 0),
 	}
 	if len(topLevelKeys) == 1 {
 		singleTopKey := topLevelKeys[0]
-		singleTopKeyValue := gjson.GetBytes(contentBytes, singleTopKey)
-		if singleTopKeyValue.IsObject() || singleTopKeyValue.IsArray() {
+		singleTopKeyValue := result.Get(gjson.Escape(singleTopKey))
+		if singleTopKeyValue.IsObject() {
 			var innerKeys []string
 			singleTopKeyValue.ForEach(func(innerKey, innerValue gjson.Result) bool {
-				if (singleTopKey == "outbounds" || singleTopKey == "inbounds") && singleTopKeyValue.IsArray() {
-					tag := innerValue.Get("tag").String()
-					if tag != "" {
-						innerKeys = append(innerKeys, tag)
-					} else {
-						innerKeys = append(innerKeys, innerKey.Str)
-					}
-				} else {
-					innerKeys = append(innerKeys, innerKey.Str)
-				}
+				innerKeys = append(innerKeys, innerKey.Str)
 				return true
 			})
 			response.RootContextKey = singleTopKey
 			response.Keys = innerKeys
+			response.Items = objectItems(singleTopKeyValue, gjson.Escape(singleTopKey), 0)
+		} else if singleTopKeyValue.IsArray() {
+			response.RootContextKey = singleTopKey
+			response.IsArray = true
+			response.Items = elementItems(singleTopKeyValue, gjson.Escape(singleTopKey), 0)
+			response.Keys = []string{}
+			for _, item := range response.Items {
+				key := strconv.Itoa(item.Index)
+				if item.Tag != "" {
+					key = item.Tag
+				}
+				response.Keys = append(response.Keys, key)
+			}
 		}
 	}
+	if response.Items == nil {
+		response.Items = []TopKeyItem{}
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	json.NewEncoder(w).Encode(response)
 }
 
 // getFileContentHandler ...
+// 参数 strip_comments=1 时返回去掉注释和尾随逗号的内容（sing-box 兼容的纯 JSON）。
 func getFileContentHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
@@ -142,7 +318,7 @@ func getFileContentHandler(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, err.Error(), http.StatusForbidden)
 		return
 	}
-	contentBytes, err := ioutil.ReadFile(filePath)
+	contentBytes, etag, err := readFileWithETag(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			writeJSONError(w, "File not found.", http.StatusNotFound)
@@ -151,18 +327,29 @@ func getFileContentHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	stripComments := r.URL.Query().Get("strip_comments") == "1"
 	var resultString string
 	if userPath != "" {
 		realPath := resolvePath(contentBytes, userPath)
-		value := gjson.GetBytes(contentBytes, realPath)
+		value := jsoncGet(contentBytes, realPath)
 		if !value.Exists() {
 			writeJSONError(w, fmt.Sprintf("路径 '%s' (解析为 '%s') 不存在。", userPath, realPath), http.StatusNotFound)
 			return
 		}
-		resultString = value.String()
+		if value.Type == gjson.JSON && !stripComments {
+			// 对象和数组返回原文片段，保留其中的注释
+			resultString = string(jsoncRaw(contentBytes, value))
+		} else {
+			resultString = value.String()
+		}
+	} else if stripComments {
+		resultString = string(jsoncToJSON(contentBytes))
 	} else {
 		resultString = string(contentBytes)
 	}
+	// 无论返回整个文件还是片段，ETag 都对应整个文件，保存时通过 If-Match 回传
+	rememberVersion(contentBytes)
+	w.Header().Set("ETag", etag)
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte(resultString))
@@ -173,10 +360,14 @@ type SaveRequestData struct {
 	Filename string `json:"filename"`
 	Content  string `json:"content"`
 	Path     string `json:"path,omitempty"`
+	Check    bool   `json:"check,omitempty"`  // 为 true 时先在临时副本上运行 sing-box check，通过后才写入
+	Format   bool   `json:"format,omitempty"` // 为 true 时保存前重新排版整个文件，未设置时由编辑器配置 format.on_save 决定
 }
 
 // saveFileContentHandler 处理 /api/save_content 请求。
-// 修改点：使用 SetRawBytes 彻底绕过标准库 JSON 校验。
+// 片段保存通过 jsoncSetRaw 只替换目标值在原文中的那一段，文件其余部分的注释和排版保持不变。
+// 请求必须携带 If-Match（来自 get_content 的 ETag），文件在此期间被修改时返回 409。
+// 写入前会校验 JSON 语法，格式错误时返回 400 并指出行列号。
 func saveFileContentHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
@@ -202,36 +393,48 @@ func saveFileContentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		writeJSONError(w, "缺少 If-Match 请求头，请重新加载文件后再保存。", http.StatusPreconditionRequired)
+		return
+	}
+
+	configWriteMutex.Lock()
+	defer configWriteMutex.Unlock()
+
+	originalContentBytes, currentETag, err := readFileWithETag(filePath)
+	if err != nil {
+		writeJSONError(w, fmt.Sprintf("无法读取原文件: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if !ifMatchSatisfied(ifMatch, currentETag) {
+		log.Printf("保存冲突: 文件 '%s', If-Match %s, 当前 %s", filename, ifMatch, currentETag)
+		writeConflictResponse(w, filename, ifMatch, originalContentBytes, currentETag)
+		return
+	}
+
 	finalContentBytes := []byte(contentToSave)
 
 	if userPath != "" {
-		originalContentBytes, err := ioutil.ReadFile(filePath)
-		if err != nil {
-			writeJSONError(w, fmt.Sprintf("无法读取原文件: %v", err), http.StatusInternalServerError)
-			return
-		}
-
 		realPath := resolvePath(originalContentBytes, userPath)
 
-		var updatedContent []byte
-
-		// ****** 终极修正：使用 SetRawBytes ******
-		// 1. 判断是否像是复杂结构（对象或数组）
+		// 判断是否像是复杂结构（对象或数组）
 		trimmedContent := strings.TrimSpace(contentToSave)
 		isLikeJSON := (strings.HasPrefix(trimmedContent, "{") && strings.HasSuffix(trimmedContent, "}")) ||
 			(strings.HasPrefix(trimmedContent, "[") && strings.HasSuffix(trimmedContent, "]"))
 
+		rawValue := []byte(trimmedContent)
 		if isLikeJSON {
-			log.Printf("检测到 JSON 结构，使用 SetRawBytes 强制写入（允许注释）。")
-			// SetRawBytes 不会检查 contentToSave 是否合法，直接插入字节
-			// 这样就完美支持了 /* 注释 */
-			updatedContent, err = sjson.SetRawBytes(originalContentBytes, realPath, []byte(contentToSave))
+			if err := validateJSONC(rawValue); err != nil {
+				writeSyntaxError(w, "片段", err.(*JSONSyntaxError))
+				return
+			}
 		} else {
-			log.Printf("检测到普通字符串，使用 SetBytes 自动转义写入。")
-			// 如果是普通字符串（比如 "debug"），还是用这个，它会自动加引号变成 "debug"
-			updatedContent, err = sjson.SetBytes(originalContentBytes, realPath, contentToSave)
+			// 普通字符串（比如 debug）自动加引号转义成 "debug"
+			rawValue, _ = json.Marshal(contentToSave)
 		}
-		// ****** 修正结束 ******
+		// 对象和数组原样插入，其中的 /* 注释 */ 会被保留
+		updatedContent, err := jsoncSetRaw(originalContentBytes, realPath, rawValue)
 
 		if err != nil {
 			log.Printf("路径修改失败: 文件 '%s', 路径 '%s' -> '%s', 错误: %v", filename, userPath, realPath, err)
@@ -242,13 +445,51 @@ func saveFileContentHandler(w http.ResponseWriter, r *http.Request) {
 		finalContentBytes = updatedContent
 	}
 
-	err = ioutil.WriteFile(filePath, finalContentBytes, 0644)
+	if err := validateConfigFile(finalContentBytes); err != nil {
+		scope := "文件"
+		if userPath != "" {
+			scope = "修改后的文件"
+		}
+		writeSyntaxError(w, scope, err.(*JSONSyntaxError))
+		return
+	}
+	if formatCfg := getEditorConfig().Format; reqData.Format || formatCfg.OnSave {
+		finalContentBytes = formatJSONC(finalContentBytes, formatIndentString(formatCfg.Indent))
+	}
+
+	// 暂存检查模式：修改先应用到配置目录的临时副本上检查，不通过则真实文件保持不变
+	if reqData.Check {
+		if output, err := checkStagedConfig(map[string][]byte{filename: finalContentBytes}); err != nil {
+			log.Printf("暂存检查未通过，放弃保存 %s: %v", filename, err)
+			writeCheckFailure(w, output, err)
+			return
+		}
+	}
+
+	// 覆盖之前先为当前内容创建备份，备份失败则拒绝保存
+	if _, err := createBackup(filename, filePath); err != nil {
+		log.Printf("无法为文件 %s 创建备份: %v", filePath, err)
+		writeJSONError(w, fmt.Sprintf("创建备份失败，已取消保存：%v", err), http.StatusInternalServerError)
+		return
+	}
+
+	err = writeConfigFile(filePath, finalContentBytes)
 	if err != nil {
 		log.Printf("无法写入文件 %s: %v", filePath, err)
-		writeJSONError(w, "保存文件失败，请检查权限。", http.StatusInternalServerError)
+		writeJSONError(w, fmt.Sprintf("保存文件失败，请检查权限：%v", err), http.StatusInternalServerError)
 		return
 	}
 
+	detail := "整个文件"
+	if userPath != "" {
+		detail = userPath
+	}
+	recordHistory(filename, sessionUser(r), detail)
+
+	if _, newETag, err := readFileWithETag(filePath); err == nil {
+		rememberVersion(finalContentBytes)
+		w.Header().Set("ETag", newETag)
+	}
 	writeJSONResponse(w, "success", "文件保存成功！", http.StatusOK)
 }
 
@@ -258,10 +499,8 @@ func restartSingboxHandler(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
 		return
 	}
-	cmd := exec.Command("sudo", "systemctl", "restart", "sing-box")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		writeJSONError(w, fmt.Sprintf("重启服务失败：%v, 详情：%s", err, string(output)), http.StatusInternalServerError)
+	if err := restartSingbox(); err != nil {
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	writeJSONResponse(w, "success", "Sing-box 服务已成功重启！", http.StatusOK)
@@ -280,17 +519,16 @@ func checkConfigHandler(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, "未设置活动配置目录。", http.StatusServiceUnavailable)
 		return
 	}
-	cmd := exec.Command("sing-box", "check", "-C", activePath)
-	output, err := cmd.CombinedOutput()
+	output, err := runSingboxCheck(activePath)
 	if err != nil {
-		writeJSONError(w, fmt.Sprintf("配置检查失败：\n%s", string(output)), http.StatusInternalServerError)
+		if output == "" {
+			writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		} else {
+			writeJSONError(w, fmt.Sprintf("配置检查失败：\n%s", output), http.StatusInternalServerError)
+		}
 		return
 	}
-	if len(output) == 0 {
-		writeJSONResponse(w, "success", "配置检查成功，无错误！", http.StatusOK)
-	} else {
-		writeJSONError(w, fmt.Sprintf("配置检查失败：\n%s", string(output)), http.StatusInternalServerError)
-	}
+	writeJSONResponse(w, "success", "配置检查成功，无错误！", http.StatusOK)
 }
 
 // 补充剩余结构体和函数...
@@ -338,6 +576,7 @@ func setActiveConfigPathHandler(w http.ResponseWriter, r *http.Request) {
 	currentConfigPathMutex.Lock()
 	currentConfigPath = newPath
 	currentConfigPathMutex.Unlock()
+	initHistory()
 	writeJSONResponse(w, "success", fmt.Sprintf("已成功设置配置目录为 '%s'。", newPath), http.StatusOK)
 }
 
@@ -360,80 +599,91 @@ func getFunctionalConfigsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var configFiles []string
-	tempFunctionalMap := make(map[string][]string)
-
-	entries, err := os.ReadDir(baseDir)
+	configFiles, err := listConfigTree()
 	if err != nil {
-		writeJSONError(w, fmt.Sprintf("无法读取配置目录 '%s'。", baseDir), http.StatusInternalServerError)
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	var unmatchedFiles []string
-	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
-			filename := entry.Name()
-			configFiles = append(configFiles, filename)
-			filePath := filepath.Join(baseDir, filename)
-			content, err := ioutil.ReadFile(filePath)
-			if err != nil {
-				continue
-			}
-			result := gjson.ParseBytes(content)
-			if !result.IsObject() {
-				unmatchedFiles = append(unmatchedFiles, filename)
-				continue
-			}
-			matched := false
-			result.ForEach(func(key, value gjson.Result) bool {
-				if info, ok := configTypeMap[key.Str]; ok {
-					tempFunctionalMap[info.FunctionName] = append(tempFunctionalMap[info.FunctionName], filename)
-					matched = true
-					return false
-				}
-				return true
-			})
-			if !matched {
-				unmatchedFiles = append(unmatchedFiles, filename)
+	// 按子目录分组：每个目录内单独归类、编号，根目录在前
+	tempFunctionalMap := make(map[string]map[string][]string) // 目录 -> 功能名 -> 文件
+	unmatchedFiles := make(map[string][]string)
+	var dirs []string
+	for _, filename := range configFiles {
+		dir := path.Dir(filename)
+		if dir == "." {
+			dir = ""
+		}
+		if _, ok := tempFunctionalMap[dir]; !ok {
+			tempFunctionalMap[dir] = make(map[string][]string)
+			dirs = append(dirs, dir)
+		}
+		content, err := ioutil.ReadFile(filepath.Join(baseDir, filepath.FromSlash(filename)))
+		if err != nil {
+			continue
+		}
+		result := jsoncParse(content)
+		if !result.IsObject() {
+			unmatchedFiles[dir] = append(unmatchedFiles[dir], filename)
+			continue
+		}
+		matched := false
+		result.ForEach(func(key, value gjson.Result) bool {
+			if info, ok := configTypeMap[key.Str]; ok {
+				tempFunctionalMap[dir][info.FunctionName] = append(tempFunctionalMap[dir][info.FunctionName], filename)
+				matched = true
+				return false
 			}
+			return true
+		})
+		if !matched {
+			unmatchedFiles[dir] = append(unmatchedFiles[dir], filename)
 		}
 	}
-	sort.Strings(configFiles)
+	sort.Strings(dirs)
+
 	var orderedFunctionalConfig []ConfigTypeInfo
-	for _, info := range configTypeMap {
-		if fileList, ok := tempFunctionalMap[info.FunctionName]; ok {
-			sort.Strings(fileList)
-			if len(fileList) == 1 {
-				orderedFunctionalConfig = append(orderedFunctionalConfig, ConfigTypeInfo{
-					FunctionName: info.FunctionName,
-					FileName:     fileList[0],
-					Order:        info.Order,
-				})
-			} else {
-				for i, fileName := range fileList {
-					orderedFunctionalConfig = append(orderedFunctionalConfig, ConfigTypeInfo{
-						FunctionName: fmt.Sprintf("%s %d", info.FunctionName, i+1),
-						FileName:     fileName,
+	for _, dir := range dirs {
+		var group []ConfigTypeInfo
+		for _, info := range configTypeMap {
+			if fileList, ok := tempFunctionalMap[dir][info.FunctionName]; ok {
+				sort.Strings(fileList)
+				if len(fileList) == 1 {
+					group = append(group, ConfigTypeInfo{
+						FunctionName: info.FunctionName,
+						FileName:     fileList[0],
 						Order:        info.Order,
+						Dir:          dir,
 					})
+				} else {
+					for i, fileName := range fileList {
+						group = append(group, ConfigTypeInfo{
+							FunctionName: fmt.Sprintf("%s %d", info.FunctionName, i+1),
+							FileName:     fileName,
+							Order:        info.Order,
+							Dir:          dir,
+						})
+					}
 				}
 			}
 		}
-	}
-	sort.Slice(orderedFunctionalConfig, func(i, j int) bool {
-		if orderedFunctionalConfig[i].Order == orderedFunctionalConfig[j].Order {
-			return orderedFunctionalConfig[i].FunctionName < orderedFunctionalConfig[j].FunctionName
-		}
-		return orderedFunctionalConfig[i].Order < orderedFunctionalConfig[j].Order
-	})
-	sort.Strings(unmatchedFiles)
-	for _, filename := range unmatchedFiles {
-		funcName := fmt.Sprintf("其他-%s", strings.TrimSuffix(filename, ".json"))
-		orderedFunctionalConfig = append(orderedFunctionalConfig, ConfigTypeInfo{
-			FunctionName: funcName,
-			FileName:     filename,
-			Order:        len(configTypeMap) + 1,
+		sort.Slice(group, func(i, j int) bool {
+			if group[i].Order == group[j].Order {
+				return group[i].FunctionName < group[j].FunctionName
+			}
+			return group[i].Order < group[j].Order
 		})
+		sort.Strings(unmatchedFiles[dir])
+		for _, filename := range unmatchedFiles[dir] {
+			funcName := fmt.Sprintf("其他-%s", strings.TrimSuffix(path.Base(filename), ".json"))
+			group = append(group, ConfigTypeInfo{
+				FunctionName: funcName,
+				FileName:     filename,
+				Order:        len(configTypeMap) + 1,
+				Dir:          dir,
+			})
+		}
+		orderedFunctionalConfig = append(orderedFunctionalConfig, group...)
 	}
 	resp := FunctionalConfigResponse{
 		OrderedFunctionalConfig: orderedFunctionalConfig,
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestResolvePath(t *testing.T) {
+	content := []byte(`{
+  // 注释
+  "outbounds": [
+    {"type": "direct", "tag": "0"},
+    {"type": "vless", "tag": "hk-01", "server": "h.example"},
+    {"type": "direct", "tag": "1"},
+    {"type": "direct", "tag": "a.b"}
+  ]
+}`)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"outbounds.hk-01", "outbounds.1"},
+		{"outbounds.hk-01.server", "outbounds.1.server"},
+		{`outbounds.a\.b`, "outbounds.3"},
+		// 纯数字是下标，不会被标签为 "1" 的元素截走
+		{"outbounds.1", "outbounds.1"},
+		{"outbounds.0.tag", "outbounds.0.tag"},
+		{"outbounds.missing", "outbounds.missing"},
+		{"log.level", "log.level"},
+	}
+	for _, tt := range tests {
+		if got := resolvePath(content, tt.path); got != tt.want {
+			t.Errorf("resolvePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	// 配置结构列表给出的路径都指向元素自身
+	for _, item := range elementItems(jsoncParse(content).Get("outbounds"), "outbounds", 0) {
+		if got, want := resolvePath(content, item.Path), "outbounds."+strconv.Itoa(item.Index); got != want {
+			t.Errorf("item %q: path %q resolves to %q, want %q", item.Label, item.Path, got, want)
+		}
+	}
+}
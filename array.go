@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ArrayElementRequest 数组元素操作（插入、删除、移动、克隆）的请求
+type ArrayElementRequest struct {
+	Filename  string `json:"filename"`
+	Path      string `json:"path"`                // 插入时为数组路径（如 outbounds），其余操作为元素路径（如 outbounds.hk-01、route.rules.3）
+	Index     *int   `json:"index,omitempty"`     // 插入位置，省略时追加到末尾
+	Content   string `json:"content,omitempty"`   // 要插入的元素
+	To        *int   `json:"to,omitempty"`        // 移动的目标位置
+	Direction string `json:"direction,omitempty"` // 移动方向 up / down，与 to 二选一
+	NewTag    string `json:"new_tag,omitempty"`   // 克隆带标签的元素时使用的新标签
+	Check     bool   `json:"check,omitempty"`     // 为 true 时先在临时副本上运行 sing-box check
+}
+
+// ArrayElementResponse ...
+type ArrayElementResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"` // 操作后元素的路径（下标形式），删除时为空
+}
+
+// arrayTagKind 返回数组中元素标签的种类，不带标签的数组返回空字符串
+func arrayTagKind(arrayPath string) string {
+	switch arrayPath {
+	case "outbounds", "endpoints":
+		return tagKindOutbound
+	case "inbounds":
+		return tagKindInbound
+	case "dns.servers":
+		return tagKindDNSServer
+	case "route.rule_set":
+		return tagKindRuleSet
+	}
+	return ""
+}
+
+// resolveElementPath 把元素路径（支持 outbounds.<tag> 这样的标签形式）解析为数组路径和下标
+func resolveElementPath(content []byte, userPath string) (string, int, error) {
+	realPath := resolvePath(content, userPath)
+	arrayPath, key := splitJSONPath(realPath)
+	index, err := strconv.Atoi(key)
+	if err != nil || arrayPath == "" {
+		return "", 0, fmt.Errorf("找不到数组元素 '%s'", userPath)
+	}
+	_, elems, err := jsoncArrayElements(content, arrayPath)
+	if err != nil {
+		return "", 0, err
+	}
+	if index < 0 || index >= len(elems) {
+		return "", 0, fmt.Errorf("找不到数组元素 '%s'", userPath)
+	}
+	return arrayPath, index, nil
+}
+
+// tagTaken 判断标签是否已在当前文件或配置目录的其他文件中定义
+func tagTaken(kind, tag, filename string, content []byte) bool {
+	for _, occ := range collectTagOccurrences(filename, content) {
+		if occ.Definition && occ.Kind == kind && occ.Tag == tag {
+			return true
+		}
+	}
+	if index, err := buildTagIndex(); err == nil {
+		if _, ok := index.definitions(kind)[tag]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareElementRaw 校验要插入的元素，并按目标数组的排版调整：
+// 多行排版时按编辑器的缩进风格重新排版并整体缩进到元素所在层级，单行排版时压缩为一行。
+func prepareElementRaw(content []byte, arrayPath string, raw []byte) ([]byte, error) {
+	if err := validateJSONC(raw); err != nil {
+		return nil, err
+	}
+	indent, multiline := jsoncArrayElementIndent(content, arrayPath)
+	if !multiline {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, jsoncToJSON(raw)); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	formatted := bytes.TrimRight(formatJSONC(raw, formatIndentString(getEditorConfig().Format.Indent)), "\n")
+	return bytes.ReplaceAll(formatted, []byte("\n"), []byte("\n"+indent)), nil
+}
+
+// applyFileEdit 是直接修改文件内容的操作（数组元素操作、导入出站等）的公共流程：校验文件名和 If-Match，
+// 计算新内容，校验语法、（可选）暂存检查，然后备份、写入并记录版本历史。
+// edit 返回新内容和用于历史记录的描述。成功时只设置 ETag 响应头，由调用方写入响应体；失败时已写入错误响应，返回 false。
+func applyFileEdit(w http.ResponseWriter, r *http.Request, filename string, check bool, edit func(content []byte) ([]byte, string, error)) bool {
+	filePath, err := validateFilename(filename)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusForbidden)
+		return false
+	}
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		writeJSONError(w, "缺少 If-Match 请求头，请重新加载文件后再修改。", http.StatusPreconditionRequired)
+		return false
+	}
+
+	configWriteMutex.Lock()
+	defer configWriteMutex.Unlock()
+
+	content, currentETag, err := readFileWithETag(filePath)
+	if err != nil {
+		writeJSONError(w, fmt.Sprintf("无法读取原文件: %v", err), http.StatusInternalServerError)
+		return false
+	}
+	if !ifMatchSatisfied(ifMatch, currentETag) {
+		writeConflictResponse(w, filename, ifMatch, content, currentETag)
+		return false
+	}
+
+	updated, detail, err := edit(content)
+	if err != nil {
+		if syntaxErr, ok := err.(*JSONSyntaxError); ok {
+			writeSyntaxError(w, "元素", syntaxErr)
+			return false
+		}
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if err := validateConfigFile(updated); err != nil {
+		writeSyntaxError(w, "修改后的文件", err.(*JSONSyntaxError))
+		return false
+	}
+	if check {
+		if output, err := checkStagedConfig(map[string][]byte{filename: updated}); err != nil {
+			writeCheckFailure(w, output, err)
+			return false
+		}
+	}
+	if _, err := createBackup(filename, filePath); err != nil {
+		writeJSONError(w, fmt.Sprintf("创建备份失败，已取消修改：%v", err), http.StatusInternalServerError)
+		return false
+	}
+	if err := writeConfigFile(filePath, updated); err != nil {
+		writeJSONError(w, fmt.Sprintf("保存文件失败，请检查权限：%v", err), http.StatusInternalServerError)
+		return false
+	}
+	recordHistory(filename, sessionUser(r), detail)
+	log.Printf("用户 '%s' 修改了 %s: %s", sessionUser(r), filename, detail)
+
+	if _, newETag, err := readFileWithETag(filePath); err == nil {
+		rememberVersion(updated)
+		w.Header().Set("ETag", newETag)
+	}
+	return true
+}
+
+// applyArrayEdit 用 applyFileEdit 执行数组元素操作。edit 返回新内容、操作后元素的路径和用于历史记录的描述。
+func applyArrayEdit(w http.ResponseWriter, r *http.Request, req *ArrayElementRequest, edit func(content []byte) ([]byte, string, string, error)) {
+	var newPath, detail string
+	ok := applyFileEdit(w, r, req.Filename, req.Check, func(content []byte) ([]byte, string, error) {
+		updated, path, message, err := edit(content)
+		newPath, detail = path, message
+		return updated, message, err
+	})
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(ArrayElementResponse{Status: "success", Message: detail, Path: newPath})
+}
+
+// decodeArrayRequest 检查请求方法并解析请求体
+func decodeArrayRequest(w http.ResponseWriter, r *http.Request) (*ArrayElementRequest, bool) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	var req ArrayElementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "无效的请求体", http.StatusBadRequest)
+		return nil, false
+	}
+	if req.Path == "" {
+		writeJSONError(w, "缺少 'path' 参数", http.StatusBadRequest)
+		return nil, false
+	}
+	return &req, true
+}
+
+// insertElementHandler 处理 /api/insert_element 请求：在数组的指定位置插入元素，省略 index 时追加到末尾
+func insertElementHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeArrayRequest(w, r)
+	if !ok {
+		return
+	}
+	applyArrayEdit(w, r, req, func(content []byte) ([]byte, string, string, error) {
+		arrayPath := req.Path
+		_, elems, err := jsoncArrayElements(content, arrayPath)
+		if err != nil {
+			return nil, "", "", err
+		}
+		index := len(elems)
+		if req.Index != nil {
+			index = *req.Index
+		}
+		raw, err := prepareElementRaw(content, arrayPath, []byte(strings.TrimSpace(req.Content)))
+		if err != nil {
+			return nil, "", "", err
+		}
+		if kind := arrayTagKind(arrayPath); kind != "" {
+			if tag := jsoncParse(raw).Get("tag").String(); tag != "" && tagTaken(kind, tag, req.Filename, content) {
+				return nil, "", "", fmt.Errorf("%s标签 '%s' 已经存在", tagKindName(kind), tag)
+			}
+		}
+		updated, err := jsoncInsertElement(content, arrayPath, index, raw, "", "")
+		if err != nil {
+			return nil, "", "", err
+		}
+		return updated, joinJSONPath(arrayPath, strconv.Itoa(index)), fmt.Sprintf("在 %s 的第 %d 个位置插入元素", arrayPath, index), nil
+	})
+}
+
+// deleteElementHandler 处理 /api/delete_element 请求：按标签或下标删除数组元素
+func deleteElementHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeArrayRequest(w, r)
+	if !ok {
+		return
+	}
+	applyArrayEdit(w, r, req, func(content []byte) ([]byte, string, string, error) {
+		arrayPath, index, err := resolveElementPath(content, req.Path)
+		if err != nil {
+			return nil, "", "", err
+		}
+		updated, err := jsoncDeleteElement(content, arrayPath, index)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return updated, "", fmt.Sprintf("删除元素 %s", req.Path), nil
+	})
+}
+
+// moveElementHandler 处理 /api/move_element 请求：把元素移动到 to 指定的位置，或按 direction 上移/下移一位
+func moveElementHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeArrayRequest(w, r)
+	if !ok {
+		return
+	}
+	applyArrayEdit(w, r, req, func(content []byte) ([]byte, string, string, error) {
+		arrayPath, from, err := resolveElementPath(content, req.Path)
+		if err != nil {
+			return nil, "", "", err
+		}
+		to := from
+		switch {
+		case req.To != nil:
+			to = *req.To
+		case req.Direction == "up":
+			to = from - 1
+		case req.Direction == "down":
+			to = from + 1
+		default:
+			return nil, "", "", fmt.Errorf("需要指定 'to' 或 'direction' (up / down)")
+		}
+		updated, err := jsoncMoveElement(content, arrayPath, from, to)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return updated, joinJSONPath(arrayPath, strconv.Itoa(to)), fmt.Sprintf("将 %s 从第 %d 个位置移到第 %d 个位置", req.Path, from, to), nil
+	})
+}
+
+// cloneElementHandler 处理 /api/clone_element 请求：复制一个元素并插入到原元素之后，原元素中的注释会一并复制。
+// 带标签的元素（如出站）必须指定新标签，规则等没有标签的元素原样复制。
+func cloneElementHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeArrayRequest(w, r)
+	if !ok {
+		return
+	}
+	req.NewTag = strings.TrimSpace(req.NewTag)
+	applyArrayEdit(w, r, req, func(content []byte) ([]byte, string, string, error) {
+		arrayPath, index, err := resolveElementPath(content, req.Path)
+		if err != nil {
+			return nil, "", "", err
+		}
+		elem := jsoncGet(content, joinJSONPath(arrayPath, strconv.Itoa(index)))
+		raw := jsoncRaw(content, elem)
+		detail := fmt.Sprintf("克隆 %s", req.Path)
+		if kind := arrayTagKind(arrayPath); kind != "" {
+			if req.NewTag == "" {
+				return nil, "", "", fmt.Errorf("缺少新标签 'new_tag'")
+			}
+			if tagTaken(kind, req.NewTag, req.Filename, content) {
+				return nil, "", "", fmt.Errorf("%s标签 '%s' 已经存在", tagKindName(kind), req.NewTag)
+			}
+			if !elem.IsObject() {
+				return nil, "", "", fmt.Errorf("元素 '%s' 不是对象，无法克隆", req.Path)
+			}
+			if raw, err = jsoncSetRaw(raw, "tag", jsonQuote(req.NewTag)); err != nil {
+				return nil, "", "", err
+			}
+			detail = fmt.Sprintf("将 %s 克隆为 '%s'", req.Path, req.NewTag)
+		}
+		if _, multiline := jsoncArrayElementIndent(content, arrayPath); !multiline {
+			if raw, err = prepareElementRaw(content, arrayPath, raw); err != nil {
+				return nil, "", "", err
+			}
+		}
+		updated, err := jsoncInsertElement(content, arrayPath, index+1, raw, "", "")
+		if err != nil {
+			return nil, "", "", err
+		}
+		return updated, joinJSONPath(arrayPath, strconv.Itoa(index+1)), detail, nil
+	})
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	sessionCookieName     = "sb_editor_session"
+	defaultSessionTTLMins = 720
+)
+
+// session 表示一个已登录的会话
+type session struct {
+	Username string
+	Expires  time.Time
+}
+
+// 全局会话表，使用互斥锁确保并发安全
+var (
+	sessions      = make(map[string]*session)
+	sessionsMutex sync.Mutex
+)
+
+// dummyPasswordHash 用于用户名不存在时的等时比较
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("sb_editor"), bcrypt.DefaultCost)
+
+// sessionUserKey 用于在请求上下文中保存当前登录用户名
+type sessionUserKey struct{}
+
+// randomToken 生成指定字节数的随机十六进制字符串
+func randomToken(n int) string {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		log.Fatalf("无法生成随机数: %v", err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// setUserPassword 创建或更新账户密码，并立即写回编辑器配置文件
+func setUserPassword(username, password string) error {
+	if username == "" || password == "" {
+		return fmt.Errorf("用户名和密码不能为空")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	editorConfigMutex.Lock()
+	defer editorConfigMutex.Unlock()
+
+	updated := false
+	for i := range editorConfig.Users {
+		if editorConfig.Users[i].Username == username {
+			editorConfig.Users[i].PasswordHash = string(hash)
+			updated = true
+			break
+		}
+	}
+	if !updated {
+		editorConfig.Users = append(editorConfig.Users, EditorUser{Username: username, PasswordHash: string(hash)})
+	}
+	return saveEditorConfigLocked()
+}
+
+// ensureDefaultUser 在没有任何账户时创建 admin 账户并打印随机初始密码
+func ensureDefaultUser() error {
+	if len(getEditorConfig().Users) > 0 {
+		return nil
+	}
+	password := randomToken(8)
+	if err := setUserPassword("admin", password); err != nil {
+		return err
+	}
+	fmt.Println("\n***** 首次运行 *****")
+	fmt.Printf("已创建默认账户 admin，初始密码: %s\n", password)
+	fmt.Printf("账户信息保存在 %s，可使用 -user/-password 参数修改。\n", editorConfigPath)
+	return nil
+}
+
+// checkCredentials 校验用户名和密码
+func checkCredentials(username, password string) bool {
+	for _, u := range getEditorConfig().Users {
+		if u.Username == username {
+			return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+		}
+	}
+	// 用户不存在时也做一次哈希比较，避免通过响应时间探测用户名
+	_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+	return false
+}
+
+// sessionFromRequest 根据 Cookie 查找有效会话，过期会话会被顺便清理
+func sessionFromRequest(r *http.Request) *session {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return nil
+	}
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+	sess, ok := sessions[cookie.Value]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(sess.Expires) {
+		delete(sessions, cookie.Value)
+		return nil
+	}
+	return sess
+}
+
+// sessionUser 返回当前请求的登录用户名（由 authMiddleware 写入）
+func sessionUser(r *http.Request) string {
+	if username, ok := r.Context().Value(sessionUserKey{}).(string); ok {
+		return username
+	}
+	return ""
+}
+
+// authMiddleware 包裹所有路由，未登录时 API 返回 401，页面跳转到登录页
+func authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" || r.URL.Path == "/api/login" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		sess := sessionFromRequest(r)
+		if sess == nil {
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				writeJSONError(w, "未登录或会话已过期，请重新登录。", http.StatusUnauthorized)
+			} else {
+				http.Redirect(w, r, "/login", http.StatusFound)
+			}
+			return
+		}
+		ctx := context.WithValue(r.Context(), sessionUserKey{}, sess.Username)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// loginPageHandler 渲染登录页面，已登录时直接跳转到主页
+func loginPageHandler(w http.ResponseWriter, r *http.Request) {
+	if sessionFromRequest(r) != nil {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := loginTemplate.Execute(w, nil); err != nil {
+		log.Printf("错误: 渲染登录模板失败: %v", err)
+		http.Error(w, "服务器内部错误：无法渲染页面。", http.StatusInternalServerError)
+	}
+}
+
+// LoginRequest ...
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// loginHandler 处理 /api/login 请求，校验成功后下发会话 Cookie
+func loginHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "无效的请求体", http.StatusBadRequest)
+		return
+	}
+	if !checkCredentials(req.Username, req.Password) {
+		log.Printf("来自 %s 的登录失败: 用户 '%s'", r.RemoteAddr, req.Username)
+		writeJSONError(w, "用户名或密码错误。", http.StatusUnauthorized)
+		return
+	}
+
+	ttl := getEditorConfig().SessionTTLMinutes
+	if ttl <= 0 {
+		ttl = defaultSessionTTLMins
+	}
+	expires := time.Now().Add(time.Duration(ttl) * time.Minute)
+	token := randomToken(32)
+
+	sessionsMutex.Lock()
+	sessions[token] = &session{Username: req.Username, Expires: expires}
+	sessionsMutex.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	log.Printf("用户 '%s' 从 %s 登录成功", req.Username, r.RemoteAddr)
+	writeJSONResponse(w, "success", "登录成功！", http.StatusOK)
+}
+
+// logoutHandler 处理 /api/logout 请求，销毁当前会话
+func logoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		sessionsMutex.Lock()
+		delete(sessions, cookie.Value)
+		sessionsMutex.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	writeJSONResponse(w, "success", "已退出登录。", http.StatusOK)
+}
+
+// currentUserHandler 返回当前登录的用户名
+func currentUserHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(map[string]string{"username": sessionUser(r)})
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// editorDataDirName 编辑器在配置目录内保存自身数据（备份等）的隐藏目录
+	editorDataDirName = ".sb_editor"
+	// backupTimeFormat 备份 ID 使用的时间格式，按字典序排序即为时间顺序
+	backupTimeFormat = "20060102-150405.000000000"
+
+	defaultBackupKeepCount = 50
+	defaultBackupKeepDays  = 30
+)
+
+// backupIDPattern 用于校验客户端传入的备份 ID，防止路径遍历
+var backupIDPattern = regexp.MustCompile(`^\d{8}-\d{6}\.\d{9}$`)
+
+// BackupInfo 描述一个备份快照
+type BackupInfo struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+}
+
+// activeConfigDir 返回当前活动配置目录
+func activeConfigDir() (string, error) {
+	currentConfigPathMutex.RLock()
+	baseDir := currentConfigPath
+	currentConfigPathMutex.RUnlock()
+	if baseDir == "" {
+		return "", fmt.Errorf("未设置配置目录，请先选择一个目录。")
+	}
+	return baseDir, nil
+}
+
+// backupDirFor 返回某个配置文件的备份目录: <配置目录>/.sb_editor/backups/<文件名>/
+func backupDirFor(filename string) (string, error) {
+	baseDir, err := activeConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(baseDir, editorDataDirName, "backups", filename), nil
+}
+
+// createBackup 在覆盖文件之前把当前内容保存为一个带时间戳的快照。
+// 文件尚不存在时什么也不做，返回空 ID。
+func createBackup(filename, filePath string) (string, error) {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("无法读取原文件以创建备份: %v", err)
+	}
+	dir, err := backupDirFor(filename)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("无法创建备份目录: %v", err)
+	}
+	id := time.Now().Format(backupTimeFormat)
+	// 配置中常含私钥和 UUID，备份一律只对所有者可读
+	if err := ioutil.WriteFile(filepath.Join(dir, id+".json"), content, 0600); err != nil {
+		return "", fmt.Errorf("无法写入备份: %v", err)
+	}
+	pruneBackups(filename)
+	return id, nil
+}
+
+// listBackups 按时间倒序列出某个文件的所有备份
+func listBackups(filename string) ([]BackupInfo, error) {
+	dir, err := backupDirFor(filename)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []BackupInfo{}, nil
+		}
+		return nil, err
+	}
+	backups := []BackupInfo{}
+	for _, entry := range entries {
+		id := strings.TrimSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !backupIDPattern.MatchString(id) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, id, time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, BackupInfo{ID: id, Time: t, Size: info.Size()})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].ID > backups[j].ID })
+	return backups, nil
+}
+
+// readBackup 读取指定备份的内容
+func readBackup(filename, id string) ([]byte, error) {
+	if !backupIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("非法的备份 ID")
+	}
+	dir, err := backupDirFor(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(filepath.Join(dir, id+".json"))
+}
+
+// pruneBackups 按保留策略清理旧备份：只保留最新的 N 个，并删除超过 D 天的备份
+func pruneBackups(filename string) {
+	policy := getEditorConfig().Backup
+	keepCount := policy.KeepCount
+	if keepCount <= 0 {
+		keepCount = defaultBackupKeepCount
+	}
+	keepDays := policy.KeepDays
+	if keepDays <= 0 {
+		keepDays = defaultBackupKeepDays
+	}
+
+	backups, err := listBackups(filename)
+	if err != nil {
+		return
+	}
+	dir, err := backupDirFor(filename)
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().AddDate(0, 0, -keepDays)
+	for i, b := range backups {
+		// 最新的一个备份总是保留，保证至少还能回退一步
+		if i == 0 {
+			continue
+		}
+		if i >= keepCount || b.Time.Before(cutoff) {
+			if err := os.Remove(filepath.Join(dir, b.ID+".json")); err != nil {
+				log.Printf("无法删除过期备份 %s/%s: %v", filename, b.ID, err)
+			}
+		}
+	}
+}
+
+// listBackupsHandler 处理 /api/list_backups?filename=...
+func listBackupsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	filename := r.URL.Query().Get("filename")
+	if _, err := validateFilename(filename); err != nil {
+		writeJSONError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	backups, err := listBackups(filename)
+	if err != nil {
+		writeJSONError(w, fmt.Sprintf("无法读取备份列表: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"filename": filename,
+		"backups":  backups,
+	})
+}
+
+// getBackupHandler 处理 /api/get_backup?filename=...&id=...，返回备份原文
+func getBackupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	filename := r.URL.Query().Get("filename")
+	if _, err := validateFilename(filename); err != nil {
+		writeJSONError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	content, err := readBackup(filename, r.URL.Query().Get("id"))
+	if err != nil {
+		writeJSONError(w, fmt.Sprintf("无法读取备份: %v", err), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
+
+// diffBackupHandler 处理 /api/diff_backup?filename=...&id=...，返回备份与当前文件的差异
+func diffBackupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	filename := r.URL.Query().Get("filename")
+	id := r.URL.Query().Get("id")
+	filePath, err := validateFilename(filename)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	backupContent, err := readBackup(filename, id)
+	if err != nil {
+		writeJSONError(w, fmt.Sprintf("无法读取备份: %v", err), http.StatusNotFound)
+		return
+	}
+	currentContent, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		writeJSONError(w, fmt.Sprintf("无法读取文件 '%s': %v", filename, err), http.StatusInternalServerError)
+		return
+	}
+	diff := unifiedDiff(fmt.Sprintf("%s@%s", filename, id), filename, backupContent, currentContent)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"filename":  filename,
+		"id":        id,
+		"identical": diff == "",
+		"diff":      diff,
+	})
+}
+
+// RestoreBackupRequest ...
+type RestoreBackupRequest struct {
+	Filename string `json:"filename"`
+	ID       string `json:"id"`
+}
+
+// restoreBackupHandler 处理 /api/restore_backup 请求。
+// 恢复前会先为当前内容创建备份，因此恢复操作本身也可以撤销。
+func restoreBackupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	var req RestoreBackupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "无效的请求体", http.StatusBadRequest)
+		return
+	}
+	filePath, err := validateFilename(req.Filename)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	content, err := readBackup(req.Filename, req.ID)
+	if err != nil {
+		writeJSONError(w, fmt.Sprintf("无法读取备份: %v", err), http.StatusNotFound)
+		return
+	}
+
+	configWriteMutex.Lock()
+	defer configWriteMutex.Unlock()
+
+	if _, err := createBackup(req.Filename, filePath); err != nil {
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := writeConfigFile(filePath, content); err != nil {
+		log.Printf("无法写入文件 %s: %v", filePath, err)
+		writeJSONError(w, fmt.Sprintf("恢复备份失败，请检查权限：%v", err), http.StatusInternalServerError)
+		return
+	}
+	recordHistory(req.Filename, sessionUser(r), fmt.Sprintf("恢复到备份 %s", req.ID))
+	log.Printf("用户 '%s' 将文件 %s 恢复到备份 %s", sessionUser(r), req.Filename, req.ID)
+	writeJSONResponse(w, "success", fmt.Sprintf("已将 '%s' 恢复到备份 %s。", req.Filename, req.ID), http.StatusOK)
+}
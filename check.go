@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// errSingboxNotFound 表示本机没有安装 sing-box，无法执行检查
+var errSingboxNotFound = errors.New("未找到 sing-box 可执行文件，无法执行配置检查")
+
+// runSingboxCheck 对目录执行 `sing-box check -C <dir>`。
+// 命令失败或有任何输出都视为检查不通过，返回的字符串为 sing-box 的输出。
+func runSingboxCheck(dir string) (string, error) {
+	cmd := exec.Command("sing-box", "check", "-C", dir)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", errSingboxNotFound
+		}
+		return string(output), fmt.Errorf("配置检查失败：%v", err)
+	}
+	if len(output) != 0 {
+		return string(output), fmt.Errorf("配置检查输出了警告或错误")
+	}
+	return "", nil
+}
+
+// stageConfigDir 把活动配置目录中 sing-box 会读取的 .json 文件复制到一个临时目录，
+// 并用 overrides 中的内容（文件名 -> 新内容）替换对应文件。调用方负责删除返回的目录。
+func stageConfigDir(overrides map[string][]byte) (string, error) {
+	baseDir, err := activeConfigDir()
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		return "", fmt.Errorf("无法读取配置目录 '%s': %v", baseDir, err)
+	}
+	tmpDir, err := ioutil.TempDir("", "sb_editor-check-")
+	if err != nil {
+		return "", fmt.Errorf("无法创建临时目录: %v", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if _, ok := overrides[name]; ok {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(baseDir, name))
+		if err != nil {
+			os.RemoveAll(tmpDir)
+			return "", fmt.Errorf("无法读取 '%s': %v", name, err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, name), content, 0600); err != nil {
+			os.RemoveAll(tmpDir)
+			return "", err
+		}
+	}
+	for name, content := range overrides {
+		target := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			os.RemoveAll(tmpDir)
+			return "", err
+		}
+		if err := ioutil.WriteFile(target, content, 0600); err != nil {
+			os.RemoveAll(tmpDir)
+			return "", err
+		}
+	}
+	return tmpDir, nil
+}
+
+// checkStagedConfig 在临时副本上应用修改并运行 sing-box check，真实目录不受影响
+func checkStagedConfig(overrides map[string][]byte) (string, error) {
+	tmpDir, err := stageConfigDir(overrides)
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+	output, err := runSingboxCheck(tmpDir)
+	// 输出中的临时目录路径对用户没有意义，替换为真实目录
+	if baseDir, dirErr := activeConfigDir(); dirErr == nil {
+		output = strings.ReplaceAll(output, tmpDir, filepath.Clean(baseDir))
+	}
+	return output, err
+}
+
+// writeCheckFailure 返回暂存检查失败的响应，附带 sing-box 的原始输出
+func writeCheckFailure(w http.ResponseWriter, output string, err error) {
+	statusCode := http.StatusUnprocessableEntity
+	message := fmt.Sprintf("配置检查未通过，文件未做任何修改：\n%s", output)
+	if errors.Is(err, errSingboxNotFound) {
+		statusCode = http.StatusServiceUnavailable
+		message = err.Error() + "，文件未做任何修改。"
+	} else if output == "" {
+		message = fmt.Sprintf("配置检查未通过，文件未做任何修改：%v", err)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":             message,
+		"output":            output,
+		"check_unavailable": errors.Is(err, errSingboxNotFound),
+	})
+}
@@ -0,0 +1,940 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v3"
+)
+
+// ClashProxy Clash / mihomo 配置中 proxies 的一项，只列出转换为 sing-box 出站时用到的字段
+type ClashProxy struct {
+	Name              string   `yaml:"name"`
+	Type              string   `yaml:"type"`
+	Server            string   `yaml:"server"`
+	Port              string   `yaml:"port"`
+	UUID              string   `yaml:"uuid"`
+	AlterID           string   `yaml:"alterId"` // 转换工具常写成字符串 "0"，与 Port 一样按字符串读取
+	Cipher            string   `yaml:"cipher"`
+	Username          string   `yaml:"username"`
+	Password          string   `yaml:"password"`
+	Flow              string   `yaml:"flow"`
+	TLS               bool     `yaml:"tls"`
+	SkipCertVerify    bool     `yaml:"skip-cert-verify"`
+	ServerName        string   `yaml:"servername"`
+	SNI               string   `yaml:"sni"`
+	ALPN              []string `yaml:"alpn"`
+	ClientFingerprint string   `yaml:"client-fingerprint"`
+	Network           string   `yaml:"network"`
+	WSOpts            struct {
+		Path                string            `yaml:"path"`
+		Headers             map[string]string `yaml:"headers"`
+		MaxEarlyData        uint32            `yaml:"max-early-data"`
+		EarlyDataHeaderName string            `yaml:"early-data-header-name"`
+	} `yaml:"ws-opts"`
+	GRPCOpts struct {
+		ServiceName string `yaml:"grpc-service-name"`
+	} `yaml:"grpc-opts"`
+	H2Opts struct {
+		Host []string `yaml:"host"`
+		Path string   `yaml:"path"`
+	} `yaml:"h2-opts"`
+	RealityOpts struct {
+		PublicKey string `yaml:"public-key"`
+		ShortID   string `yaml:"short-id"`
+	} `yaml:"reality-opts"`
+	Smux struct {
+		Enabled  bool   `yaml:"enabled"`
+		Protocol string `yaml:"protocol"`
+		Padding  bool   `yaml:"padding"`
+	} `yaml:"smux"`
+	Plugin               string                 `yaml:"plugin"`
+	PluginOpts           map[string]interface{} `yaml:"plugin-opts"`
+	Obfs                 string                 `yaml:"obfs"`
+	ObfsPassword         string                 `yaml:"obfs-password"`
+	Ports                string                 `yaml:"ports"`
+	Up                   string                 `yaml:"up"`
+	Down                 string                 `yaml:"down"`
+	CongestionController string                 `yaml:"congestion-controller"`
+	UDPRelayMode         string                 `yaml:"udp-relay-mode"`
+	DisableSNI           bool                   `yaml:"disable-sni"`
+}
+
+// clashTLS 把 Clash 的 tls / sni / skip-cert-verify / reality-opts 等字段转换为出站 TLS，enabled 为 true 时总是启用
+func clashTLS(p *ClashProxy, enabled bool) *OutboundTLS {
+	if !enabled && !p.TLS {
+		return nil
+	}
+	tls := &OutboundTLS{Enabled: true, ServerName: p.SNI, Insecure: p.SkipCertVerify, ALPN: p.ALPN}
+	if tls.ServerName == "" {
+		tls.ServerName = p.ServerName
+	}
+	fingerprint := p.ClientFingerprint
+	if p.RealityOpts.PublicKey != "" {
+		tls.Reality = &OutboundReality{Enabled: true, PublicKey: p.RealityOpts.PublicKey, ShortID: p.RealityOpts.ShortID}
+		if fingerprint == "" {
+			fingerprint = "chrome"
+		}
+	}
+	if fingerprint != "" {
+		tls.UTLS = &OutboundUTLS{Enabled: true, Fingerprint: fingerprint}
+	}
+	return tls
+}
+
+// clashTransport 把 Clash 的 network 及 *-opts 转换为 V2Ray 传输层
+func clashTransport(p *ClashProxy) (json.RawMessage, error) {
+	switch p.Network {
+	case "", "tcp":
+		return nil, nil
+	case "ws":
+		ws := WebsocketTransport{
+			Type:                "ws",
+			Path:                p.WSOpts.Path,
+			MaxEarlyData:        p.WSOpts.MaxEarlyData,
+			EarlyDataHeaderName: p.WSOpts.EarlyDataHeaderName,
+		}
+		if len(p.WSOpts.Headers) > 0 {
+			ws.Headers = make(map[string]StringList)
+			for key, value := range p.WSOpts.Headers {
+				ws.Headers[key] = StringList{value}
+			}
+		}
+		return json.Marshal(ws)
+	case "grpc":
+		return json.Marshal(GRPCTransport{Type: "grpc", ServiceName: p.GRPCOpts.ServiceName})
+	case "h2":
+		return json.Marshal(HTTPTransport{Type: "http", Host: p.H2Opts.Host, Path: p.H2Opts.Path})
+	}
+	return nil, fmt.Errorf("不支持的传输方式 '%s'", p.Network)
+}
+
+// clashMultiplex 转换 smux 设置
+func clashMultiplex(p *ClashProxy) *OutboundMultiplex {
+	if !p.Smux.Enabled {
+		return nil
+	}
+	return &OutboundMultiplex{Enabled: true, Protocol: p.Smux.Protocol, Padding: p.Smux.Padding}
+}
+
+// clashBandwidth 解析 "100"、"100 Mbps" 形式的带宽，单位为 Mbps
+func clashBandwidth(value string) int {
+	value = strings.TrimSpace(value)
+	end := 0
+	for end < len(value) && value[end] >= '0' && value[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(value[:end])
+	return n
+}
+
+// clashPluginOpts 把 Shadowsocks 插件的 plugin-opts 转换为 SIP003 的 plugin_opts 字符串
+func clashPluginOpts(plugin string, opts map[string]interface{}) (string, string, error) {
+	get := func(key string) string {
+		if value, ok := opts[key]; ok && value != nil {
+			return fmt.Sprint(value)
+		}
+		return ""
+	}
+	var parts []string
+	switch plugin {
+	case "obfs":
+		parts = append(parts, "obfs="+get("mode"))
+		if host := get("host"); host != "" {
+			parts = append(parts, "obfs-host="+host)
+		}
+		return "obfs-local", strings.Join(parts, ";"), nil
+	case "v2ray-plugin":
+		if mode := get("mode"); mode != "" {
+			parts = append(parts, "mode="+mode)
+		}
+		if get("tls") == "true" {
+			parts = append(parts, "tls")
+		}
+		if host := get("host"); host != "" {
+			parts = append(parts, "host="+host)
+		}
+		if path := get("path"); path != "" {
+			parts = append(parts, "path="+path)
+		}
+		if get("mux") == "true" {
+			parts = append(parts, "mux=1")
+		}
+		return "v2ray-plugin", strings.Join(parts, ";"), nil
+	}
+	return "", "", fmt.Errorf("不支持的 Shadowsocks 插件 '%s'", plugin)
+}
+
+// convertClashProxy 把一个 Clash 代理转换为 sing-box 出站结构体
+func convertClashProxy(p *ClashProxy) (interface{}, error) {
+	if p.Name == "" || p.Server == "" {
+		return nil, fmt.Errorf("缺少 name 或 server")
+	}
+	port, err := parseServerPort(p.Port)
+	if err != nil {
+		return nil, err
+	}
+	server := ServerFields{Server: p.Server, ServerPort: port}
+	base := func(typ string) OutboundBase { return OutboundBase{Type: typ, Tag: p.Name} }
+
+	switch p.Type {
+	case "ss":
+		out := &ShadowsocksOutbound{
+			OutboundBase: base("shadowsocks"),
+			ServerFields: server,
+			Method:       p.Cipher,
+			Password:     p.Password,
+			Multiplex:    clashMultiplex(p),
+		}
+		if p.Plugin != "" {
+			if out.Plugin, out.PluginOpts, err = clashPluginOpts(p.Plugin, p.PluginOpts); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case "vmess":
+		transport, err := clashTransport(p)
+		if err != nil {
+			return nil, err
+		}
+		security := p.Cipher
+		if security == "" {
+			security = "auto"
+		}
+		alterID := 0
+		if p.AlterID != "" {
+			if alterID, err = strconv.Atoi(p.AlterID); err != nil || alterID < 0 {
+				return nil, fmt.Errorf("无效的 alterId '%s'", p.AlterID)
+			}
+		}
+		return &VMessOutbound{
+			OutboundBase: base("vmess"),
+			ServerFields: server,
+			UUID:         p.UUID,
+			Security:     security,
+			AlterID:      alterID,
+			TLS:          clashTLS(p, false),
+			Transport:    transport,
+			Multiplex:    clashMultiplex(p),
+		}, nil
+	case "vless":
+		transport, err := clashTransport(p)
+		if err != nil {
+			return nil, err
+		}
+		return &VLESSOutbound{
+			OutboundBase: base("vless"),
+			ServerFields: server,
+			UUID:         p.UUID,
+			Flow:         p.Flow,
+			TLS:          clashTLS(p, false),
+			Transport:    transport,
+			Multiplex:    clashMultiplex(p),
+		}, nil
+	case "trojan":
+		transport, err := clashTransport(p)
+		if err != nil {
+			return nil, err
+		}
+		return &TrojanOutbound{
+			OutboundBase: base("trojan"),
+			ServerFields: server,
+			Password:     p.Password,
+			TLS:          clashTLS(p, true),
+			Transport:    transport,
+			Multiplex:    clashMultiplex(p),
+		}, nil
+	case "hysteria2":
+		out := &Hysteria2Outbound{
+			OutboundBase: base("hysteria2"),
+			ServerFields: server,
+			Password:     p.Password,
+			UpMbps:       clashBandwidth(p.Up),
+			DownMbps:     clashBandwidth(p.Down),
+			TLS:          clashTLS(p, true),
+		}
+		if p.Obfs != "" {
+			out.Obfs = &Hysteria2Obfs{Type: p.Obfs, Password: p.ObfsPassword}
+		}
+		for _, r := range splitList(p.Ports) {
+			out.ServerPorts = append(out.ServerPorts, strings.Replace(r, "-", ":", 1))
+		}
+		return out, nil
+	case "tuic":
+		tls := clashTLS(p, true)
+		tls.DisableSNI = p.DisableSNI
+		if len(tls.ALPN) == 0 {
+			tls.ALPN = StringList{"h3"}
+		}
+		return &TUICOutbound{
+			OutboundBase:      base("tuic"),
+			ServerFields:      server,
+			UUID:              p.UUID,
+			Password:          p.Password,
+			CongestionControl: p.CongestionController,
+			UDPRelayMode:      p.UDPRelayMode,
+			TLS:               tls,
+		}, nil
+	case "anytls":
+		return &AnyTLSOutbound{
+			OutboundBase: base("anytls"),
+			ServerFields: server,
+			Password:     p.Password,
+			TLS:          clashTLS(p, true),
+		}, nil
+	case "socks5":
+		return &SOCKSOutbound{
+			OutboundBase: base("socks"),
+			ServerFields: server,
+			Version:      "5",
+			Username:     p.Username,
+			Password:     p.Password,
+		}, nil
+	case "http":
+		return &HTTPOutbound{
+			OutboundBase: base("http"),
+			ServerFields: server,
+			Username:     p.Username,
+			Password:     p.Password,
+			TLS:          clashTLS(p, false),
+		}, nil
+	}
+	return nil, fmt.Errorf("不支持的代理类型 '%s'", p.Type)
+}
+
+// parseClashProxies 解析 Clash 配置中的 proxies，每个代理单独解码，个别代理格式错误不影响其他代理
+func parseClashProxies(proxies []yaml.Node) []ImportedOutbound {
+	items := make([]ImportedOutbound, 0, len(proxies))
+	for i := range proxies {
+		var p ClashProxy
+		item := ImportedOutbound{Link: fmt.Sprintf("proxies[%d]", i)}
+		err := proxies[i].Decode(&p)
+		if err == nil {
+			item.Link = p.Name
+			var outbound interface{}
+			if outbound, err = convertClashProxy(&p); err == nil {
+				item.Outbound, err = json.Marshal(outbound)
+			}
+		}
+		if err != nil {
+			item.Error = err.Error()
+		} else {
+			item.Tag = p.Name
+			item.Type = gjson.GetBytes(item.Outbound, "type").String()
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// ---------- 完整配置转换 ----------
+
+// ClashProxyGroup Clash 配置中 proxy-groups 的一项
+type ClashProxyGroup struct {
+	Name              string   `yaml:"name"`
+	Type              string   `yaml:"type"`
+	Proxies           []string `yaml:"proxies"`
+	Use               []string `yaml:"use"`
+	URL               string   `yaml:"url"`
+	Interval          int      `yaml:"interval"`
+	Tolerance         uint16   `yaml:"tolerance"`
+	IncludeAll        bool     `yaml:"include-all"`
+	IncludeAllProxies bool     `yaml:"include-all-proxies"`
+	Filter            string   `yaml:"filter"`
+}
+
+// ClashRuleProvider Clash 配置中 rule-providers 的一项
+type ClashRuleProvider struct {
+	Type     string `yaml:"type"`
+	Behavior string `yaml:"behavior"`
+	Format   string `yaml:"format"`
+	URL      string `yaml:"url"`
+}
+
+// ClashConfig 转换时读取的 Clash 配置部分
+type ClashConfig struct {
+	Proxies       []yaml.Node                  `yaml:"proxies"`
+	ProxyGroups   []ClashProxyGroup            `yaml:"proxy-groups"`
+	Rules         []string                     `yaml:"rules"`
+	RuleProviders map[string]ClashRuleProvider `yaml:"rule-providers"`
+}
+
+// clashConvertedKeys 会被转换（或对 sing-box 没有意义、可以忽略）的 Clash 顶层配置项，其余的都会列为未转换
+var clashConvertedKeys = map[string]bool{
+	"proxies": true, "proxy-groups": true, "rules": true, "rule-providers": true,
+	"port": true, "socks-port": true, "mixed-port": true, "redir-port": true, "allow-lan": true, "bind-address": true,
+	"mode": true, "log-level": true, "external-controller": true, "secret": true, "ipv6": true, "unified-delay": true,
+}
+
+// clashRuleFields Clash 规则类型对应的 sing-box 路由规则字段
+var clashRuleFields = map[string]string{
+	"DOMAIN":         "domain",
+	"DOMAIN-SUFFIX":  "domain_suffix",
+	"DOMAIN-KEYWORD": "domain_keyword",
+	"DOMAIN-REGEX":   "domain_regex",
+	"IP-CIDR":        "ip_cidr",
+	"IP-CIDR6":       "ip_cidr",
+	"SRC-IP-CIDR":    "source_ip_cidr",
+	"DST-PORT":       "port",
+	"SRC-PORT":       "source_port",
+	"PROCESS-NAME":   "process_name",
+	"PROCESS-PATH":   "process_path",
+	"NETWORK":        "network",
+	"GEOIP":          "rule_set",
+	"GEOSITE":        "rule_set",
+	"RULE-SET":       "rule_set",
+}
+
+// ClashIssue 一项无法（或只能近似）转换的内容
+type ClashIssue struct {
+	Item   string `json:"item"`
+	Reason string `json:"reason"`
+}
+
+// clashRouteRule 转换出的一条路由规则：一个匹配字段加上动作。相邻且字段和动作都相同的 Clash 规则会合并为一条。
+type clashRouteRule struct {
+	Field    string
+	Values   []interface{}
+	Outbound string // 为空表示 reject
+	Method   string // reject 的方式，drop 表示静默丢弃
+}
+
+// MarshalJSON 按 匹配字段、动作 的顺序输出
+func (rule clashRouteRule) MarshalJSON() ([]byte, error) {
+	var value interface{} = rule.Values
+	if rule.Field == "ip_is_private" {
+		value = true
+	} else if len(rule.Values) == 1 {
+		value = rule.Values[0]
+	}
+	field, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	raw := fmt.Sprintf("{%s: %s", jsonQuote(rule.Field), field)
+	switch {
+	case rule.Outbound != "":
+		raw += fmt.Sprintf(`, "outbound": %s}`, jsonQuote(rule.Outbound))
+	case rule.Method != "":
+		raw += fmt.Sprintf(`, "action": "reject", "method": %s}`, jsonQuote(rule.Method))
+	default:
+		raw += `, "action": "reject"}`
+	}
+	return []byte(raw), nil
+}
+
+// clashGeoRuleSet 返回 GEOIP / GEOSITE 对应的 SagerNet 远程规则集
+func clashGeoRuleSet(kind, code string) RuleSetEntry {
+	code = strings.ToLower(code)
+	entry := RuleSetEntry{Type: "remote", Format: "binary"}
+	if kind == "GEOIP" {
+		entry.Tag = "geoip-" + code
+		entry.URL = fmt.Sprintf("https://raw.githubusercontent.com/SagerNet/sing-geoip/rule-set/geoip-%s.srs", code)
+	} else {
+		entry.Tag = "geosite-" + code
+		entry.URL = fmt.Sprintf("https://raw.githubusercontent.com/SagerNet/sing-geosite/rule-set/geosite-%s.srs", code)
+	}
+	return entry
+}
+
+// clashConversion 一次 Clash 配置转换的结果
+type clashConversion struct {
+	Outbounds   []json.RawMessage
+	Groups      int
+	Rules       []clashRouteRule
+	RuleSets    []RuleSetEntry
+	Final       string
+	Unconverted []ClashIssue
+}
+
+// convertClashConfig 把 Clash 配置转换为 sing-box 出站和路由规则。
+// outboundTaken / ruleSetTaken 判断标签是否已在配置目录中定义：冲突的出站标签自动加后缀，已定义的规则集直接引用；
+// hasFinal 表示目录中已经设置了 route.final，此时不转换 MATCH。
+func convertClashConfig(content []byte, outboundTaken, ruleSetTaken func(string) bool, hasFinal bool) (*clashConversion, error) {
+	var root map[string]yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("不是有效的 YAML: %v", err)
+	}
+	var cfg ClashConfig
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return nil, fmt.Errorf("Clash 配置格式错误: %v", err)
+	}
+	if len(cfg.Proxies) == 0 && len(cfg.ProxyGroups) == 0 {
+		return nil, fmt.Errorf("配置中没有 proxies 或 proxy-groups")
+	}
+	result := &clashConversion{}
+	report := func(item, reason string, args ...interface{}) {
+		result.Unconverted = append(result.Unconverted, ClashIssue{Item: item, Reason: fmt.Sprintf(reason, args...)})
+	}
+	var keys []string
+	for key := range root {
+		if !clashConvertedKeys[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		report(key, "不在转换范围内，请在 sing-box 中手动配置")
+	}
+
+	// 代理和代理组共用一个标签空间，与目录中已有的出站冲突时加后缀
+	tags := make(map[string]string) // Clash 名称 -> sing-box 标签
+	used := make(map[string]bool)
+	uniqueTag := func(name string) string {
+		tag := name
+		for n := 2; used[tag] || outboundTaken(tag); n++ {
+			tag = fmt.Sprintf("%s-%d", name, n)
+		}
+		used[tag] = true
+		if tag != name {
+			report(name, "标签与已有出站冲突，已改名为 '%s'", tag)
+		}
+		return tag
+	}
+
+	var proxyNames []string
+	proxies := make(map[string]ImportedOutbound)
+	for _, item := range parseClashProxies(cfg.Proxies) {
+		if item.Error != "" {
+			report(item.Link, "%s", item.Error)
+			continue
+		}
+		if _, ok := proxies[item.Tag]; ok {
+			report(item.Link, "代理名称重复，只保留第一个")
+			continue
+		}
+		proxyNames = append(proxyNames, item.Tag)
+		proxies[item.Tag] = item
+	}
+
+	// 先确定哪些代理组可以转换，再反复去掉成员为空的组（组可以引用后面定义的组）
+	groups := make(map[string]*ClashProxyGroup)
+	var groupNames []string
+	for i := range cfg.ProxyGroups {
+		g := &cfg.ProxyGroups[i]
+		_, isProxy := proxies[g.Name]
+		if _, isGroup := groups[g.Name]; isProxy || isGroup {
+			report(g.Name, "代理组名称与其他代理或代理组重复，未转换")
+			continue
+		}
+		switch g.Type {
+		case "select", "url-test":
+		case "fallback", "load-balance":
+			report(g.Name, "sing-box 没有 %s，已近似转换为 urltest（自动选择延迟最低的节点）", g.Type)
+		default:
+			report(g.Name, "不支持的代理组类型 '%s'", g.Type)
+			continue
+		}
+		if len(g.Use) > 0 {
+			report(g.Name, "引用的 proxy-providers（%s）未转换", strings.Join(g.Use, ", "))
+		}
+		if g.IncludeAll || g.IncludeAllProxies {
+			var filter *regexp.Regexp
+			if g.Filter != "" {
+				var err error
+				if filter, err = regexp.Compile(g.Filter); err != nil {
+					report(g.Name, "filter 不是有效的正则表达式，已忽略")
+				}
+			}
+			for _, name := range proxyNames {
+				if filter == nil || filter.MatchString(name) {
+					g.Proxies = append(g.Proxies, name)
+				}
+			}
+		}
+		groups[g.Name] = g
+		groupNames = append(groupNames, g.Name)
+	}
+	// 反复去掉没有可用成员的代理组，直到不再变化
+	members := make(map[string][]string)
+	for changed := true; changed; {
+		changed = false
+		for _, name := range groupNames {
+			g, ok := groups[name]
+			if !ok {
+				continue
+			}
+			var list []string
+			for _, member := range g.Proxies {
+				_, isProxy := proxies[member]
+				_, isGroup := groups[member]
+				if isProxy || isGroup || member == "DIRECT" {
+					list = append(list, member)
+				}
+			}
+			if len(list) == 0 {
+				report(name, "代理组没有可用的成员，未转换")
+				delete(groups, name)
+				changed = true
+				continue
+			}
+			members[name] = list
+		}
+	}
+	needDirect := false
+	for _, name := range groupNames {
+		g, ok := groups[name]
+		if !ok {
+			continue
+		}
+		for _, member := range g.Proxies {
+			_, isProxy := proxies[member]
+			_, isGroup := groups[member]
+			switch {
+			case member == "DIRECT":
+				needDirect = true
+			case member == "REJECT" || member == "REJECT-DROP":
+				report(fmt.Sprintf("%s → %s", name, member), "sing-box 的代理组不能包含 REJECT，已去掉")
+			case !isProxy && !isGroup:
+				report(fmt.Sprintf("%s → %s", name, member), "成员不存在或无法转换，已从代理组中去掉")
+			}
+		}
+	}
+
+	type parsedRule struct {
+		line, kind, value, target string
+	}
+	var rules []parsedRule
+	for _, line := range cfg.Rules {
+		parts := strings.Split(line, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		kind := strings.ToUpper(parts[0])
+		rule := parsedRule{line: line, kind: kind}
+		switch {
+		case kind == "MATCH" || kind == "FINAL":
+			if len(parts) < 2 {
+				report(line, "规则格式错误")
+				continue
+			}
+			rule.kind, rule.target = "MATCH", parts[1]
+		case len(parts) < 3:
+			report(line, "规则格式错误")
+			continue
+		case clashRuleFields[kind] == "":
+			report(line, "不支持的规则类型 '%s'", kind)
+			continue
+		default:
+			rule.value, rule.target = parts[1], parts[2]
+		}
+		if rule.target == "DIRECT" {
+			needDirect = true
+		}
+		rules = append(rules, rule)
+	}
+
+	// 分配标签：代理、代理组、direct
+	for _, name := range proxyNames {
+		tags[name] = uniqueTag(name)
+	}
+	for _, name := range groupNames {
+		if _, ok := groups[name]; ok {
+			tags[name] = uniqueTag(name)
+		}
+	}
+	if needDirect {
+		// 目录中已有 direct 出站时直接引用，否则在代理之后新增一个
+		tags["DIRECT"] = "direct"
+		if !outboundTaken("direct") {
+			tags["DIRECT"] = uniqueTag("direct")
+		}
+	}
+
+	for _, name := range groupNames {
+		g, ok := groups[name]
+		if !ok {
+			continue
+		}
+		var list StringList
+		for _, member := range members[name] {
+			list = append(list, tags[member])
+		}
+		var outbound interface{}
+		if g.Type == "select" {
+			outbound = SelectorOutbound{OutboundBase: OutboundBase{Type: "selector", Tag: tags[name]}, Outbounds: list}
+		} else {
+			urltest := URLTestOutbound{OutboundBase: OutboundBase{Type: "urltest", Tag: tags[name]}, Outbounds: list, URL: g.URL, Tolerance: g.Tolerance}
+			if g.Interval > 0 {
+				urltest.Interval = Duration(fmt.Sprintf("%ds", g.Interval))
+			}
+			outbound = urltest
+		}
+		raw, err := json.Marshal(outbound)
+		if err != nil {
+			return nil, err
+		}
+		result.Outbounds = append(result.Outbounds, raw)
+		result.Groups++
+	}
+	for _, name := range proxyNames {
+		raw := proxies[name].Outbound
+		if tags[name] != name {
+			var err error
+			if raw, err = jsoncSetRaw(raw, "tag", jsonQuote(tags[name])); err != nil {
+				return nil, err
+			}
+		}
+		result.Outbounds = append(result.Outbounds, raw)
+	}
+	if needDirect && !outboundTaken("direct") {
+		raw, err := json.Marshal(DirectOutbound{OutboundBase: OutboundBase{Type: "direct", Tag: tags["DIRECT"]}})
+		if err != nil {
+			return nil, err
+		}
+		result.Outbounds = append(result.Outbounds, raw)
+	}
+
+	// 转换规则，相邻的同类规则合并
+	ruleSets := make(map[string]bool)
+	for _, rule := range rules {
+		converted := clashRouteRule{}
+		switch rule.target {
+		case "REJECT":
+		case "REJECT-DROP":
+			converted.Method = "drop"
+		default:
+			tag, ok := tags[rule.target]
+			if !ok {
+				report(rule.line, "目标 '%s' 不存在或未能转换", rule.target)
+				continue
+			}
+			converted.Outbound = tag
+		}
+		if rule.kind == "MATCH" {
+			if converted.Outbound == "" {
+				report(rule.line, "sing-box 的 route.final 只能是出站，不能是 REJECT")
+			} else if hasFinal {
+				report(rule.line, "配置目录中已经设置了 route.final，未覆盖")
+			} else {
+				result.Final = converted.Outbound
+			}
+			continue
+		}
+		converted.Field = clashRuleFields[rule.kind]
+		var value interface{} = rule.value
+		switch rule.kind {
+		case "GEOIP", "GEOSITE":
+			if rule.kind == "GEOIP" && (strings.EqualFold(rule.value, "LAN") || strings.EqualFold(rule.value, "private")) {
+				converted.Field = "ip_is_private"
+				break
+			}
+			entry := clashGeoRuleSet(rule.kind, rule.value)
+			value = entry.Tag
+			if !ruleSets[entry.Tag] && !ruleSetTaken(entry.Tag) {
+				result.RuleSets = append(result.RuleSets, entry)
+			}
+			ruleSets[entry.Tag] = true
+		case "RULE-SET":
+			if !ruleSets[rule.value] && !ruleSetTaken(rule.value) {
+				provider, ok := cfg.RuleProviders[rule.value]
+				format := ""
+				switch {
+				case !ok:
+				case strings.HasSuffix(provider.URL, ".srs"):
+					format = "binary"
+				case strings.HasSuffix(provider.URL, ".json"):
+					format = "source"
+				}
+				if format == "" || provider.Type != "http" {
+					report(rule.line, "规则集 '%s' 是 Clash 格式，sing-box 无法直接使用；请先在配置目录中定义同名的 rule_set 再重新导入", rule.value)
+					continue
+				}
+				result.RuleSets = append(result.RuleSets, RuleSetEntry{Type: "remote", Tag: rule.value, Format: format, URL: provider.URL})
+			}
+			ruleSets[rule.value] = true
+		case "DST-PORT", "SRC-PORT":
+			if strings.Contains(rule.value, "-") {
+				converted.Field += "_range"
+				value = strings.Replace(rule.value, "-", ":", 1)
+			} else if port, err := strconv.ParseUint(rule.value, 10, 16); err == nil {
+				value = port
+			} else {
+				report(rule.line, "无效的端口")
+				continue
+			}
+		case "NETWORK":
+			value = strings.ToLower(rule.value)
+		}
+		converted.Values = []interface{}{value}
+		if n := len(result.Rules); n > 0 {
+			last := &result.Rules[n-1]
+			if last.Field == converted.Field && last.Outbound == converted.Outbound && last.Method == converted.Method && converted.Field != "ip_is_private" {
+				last.Values = append(last.Values, value)
+				continue
+			}
+		}
+		result.Rules = append(result.Rules, converted)
+	}
+	return result, nil
+}
+
+// writeNewConfigFiles 按文件名顺序创建 contents 中的新文件（paths 给出各文件的完整路径），返回写入的文件名。
+// 任何一个写入失败时删除已经创建的文件，保证这些文件要么都创建要么都不创建。
+func writeNewConfigFiles(paths map[string]string, contents map[string][]byte) ([]string, error) {
+	var names, written []string
+	for name := range contents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeConfigFile(paths[name], contents[name]); err != nil {
+			for _, created := range written {
+				if removeErr := os.Remove(paths[created]); removeErr != nil {
+					log.Printf("Clash 导入: 无法删除 '%s': %v", created, removeErr)
+				}
+			}
+			return nil, fmt.Errorf("写入 '%s' 失败，已撤销全部修改: %v", name, err)
+		}
+		written = append(written, name)
+	}
+	return written, nil
+}
+
+// ImportClashRequest ...
+type ImportClashRequest struct {
+	Content       string `json:"content"`                  // Clash / Clash.Meta 的 YAML 配置
+	OutboundsFile string `json:"outbounds_file,omitempty"` // 写入代理和代理组的新文件，默认 50_clash_outbounds.json
+	RouteFile     string `json:"route_file,omitempty"`     // 写入路由规则的新文件，默认 51_clash_route.json
+	Preview       bool   `json:"preview,omitempty"`        // 为 true 时只返回转换结果，不写入文件
+	Check         bool   `json:"check,omitempty"`          // 为 true 时先在临时副本上运行 sing-box check
+}
+
+// ImportClashResponse ...
+type ImportClashResponse struct {
+	Status      string            `json:"status"`
+	Message     string            `json:"message"`
+	Files       map[string]string `json:"files"` // 文件名 -> 内容
+	Outbounds   int               `json:"outbounds"`
+	Groups      int               `json:"groups"`
+	Rules       int               `json:"rules"`
+	Unconverted []ClashIssue      `json:"unconverted"`
+}
+
+// importClashHandler 处理 /api/import_clash 请求：把 Clash 配置转换为 sing-box 的出站文件和路由文件，
+// 两个文件都必须是新文件；无法转换的内容在 unconverted 中逐项列出。
+func importClashHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	var req ImportClashRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "无效的请求体", http.StatusBadRequest)
+		return
+	}
+	if req.OutboundsFile == "" {
+		req.OutboundsFile = "50_clash_outbounds.json"
+	}
+	if req.RouteFile == "" {
+		req.RouteFile = "51_clash_route.json"
+	}
+	if req.OutboundsFile == req.RouteFile {
+		writeJSONError(w, "出站文件和路由文件不能相同", http.StatusBadRequest)
+		return
+	}
+
+	configWriteMutex.Lock()
+	defer configWriteMutex.Unlock()
+
+	index, err := buildTagIndex()
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	outbounds := index.definitions(tagKindOutbound)
+	ruleSets := index.definitions(tagKindRuleSet)
+	conversion, err := convertClashConfig([]byte(req.Content),
+		func(tag string) bool { _, ok := outbounds[tag]; return ok },
+		func(tag string) bool { _, ok := ruleSets[tag]; return ok },
+		index.HasFinal)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	indent := formatIndentString(getEditorConfig().Format.Indent)
+	header := "// 由 Clash 配置转换生成\n"
+	files := make(map[string]string)
+	content, err := json.Marshal(map[string]interface{}{"outbounds": conversion.Outbounds})
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	files[req.OutboundsFile] = header + string(formatJSONC(content, indent))
+	if len(conversion.Rules) > 0 || len(conversion.RuleSets) > 0 || conversion.Final != "" {
+		route := struct {
+			Rules   []clashRouteRule `json:"rules"`
+			RuleSet []RuleSetEntry   `json:"rule_set,omitempty"`
+			Final   string           `json:"final,omitempty"`
+		}{conversion.Rules, conversion.RuleSets, conversion.Final}
+		if route.Rules == nil {
+			route.Rules = []clashRouteRule{}
+		}
+		content, err := json.Marshal(map[string]interface{}{"route": route})
+		if err != nil {
+			writeJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		files[req.RouteFile] = header + string(formatJSONC(content, indent))
+	}
+	response := ImportClashResponse{
+		Status:      "success",
+		Files:       files,
+		Outbounds:   len(conversion.Outbounds) - conversion.Groups,
+		Groups:      conversion.Groups,
+		Rules:       len(conversion.Rules),
+		Unconverted: conversion.Unconverted,
+	}
+	if response.Unconverted == nil {
+		response.Unconverted = []ClashIssue{}
+	}
+	summary := fmt.Sprintf("%d 个出站、%d 个代理组、%d 条路由规则，%d 项未能完整转换", response.Outbounds, response.Groups, response.Rules, len(response.Unconverted))
+
+	if !req.Preview {
+		paths := make(map[string]string)
+		overrides := make(map[string][]byte)
+		for name, content := range files {
+			filePath, err := validateNewFilename(name)
+			if err != nil {
+				writeFileOpError(w, name, err)
+				return
+			}
+			if strings.Contains(name, "/") {
+				writeJSONError(w, fmt.Sprintf("'%s' 必须位于配置目录根目录，sing-box 不会读取子目录", name), http.StatusBadRequest)
+				return
+			}
+			paths[name] = filePath
+			overrides[name] = []byte(content)
+		}
+		if req.Check {
+			if output, err := checkStagedConfig(overrides); err != nil {
+				writeCheckFailure(w, output, err)
+				return
+			}
+		}
+		written, err := writeNewConfigFiles(paths, overrides)
+		if err != nil {
+			writeJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if historyEnabled() {
+			if err := commitHistory(written, sessionUser(r), "从 Clash 配置导入: "+strings.Join(written, ", ")); err != nil {
+				log.Printf("版本历史: %v", err)
+			}
+		}
+		log.Printf("用户 '%s' 从 Clash 配置导入了 %s", sessionUser(r), strings.Join(written, ", "))
+		summary = fmt.Sprintf("已写入 %s：%s", strings.Join(written, "、"), summary)
+	}
+	response.Message = summary
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(response)
+}
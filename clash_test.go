@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseClashProxiesAlterID(t *testing.T) {
+	var cfg ClashConfig
+	content := `proxies:
+  - {name: quoted, type: vmess, server: a.example, port: "443", uuid: u, alterId: "0"}
+  - {name: number, type: vmess, server: a.example, port: 443, uuid: u, alterId: 2}
+  - {name: missing, type: vmess, server: a.example, port: 443, uuid: u}
+  - {name: invalid, type: vmess, server: a.example, port: 443, uuid: u, alterId: auto}
+`
+	if err := yaml.Unmarshal([]byte(content), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	items := parseClashProxies(cfg.Proxies)
+	want := []struct {
+		alterID int64
+		err     bool
+	}{{0, false}, {2, false}, {0, false}, {0, true}}
+	for i, item := range items {
+		if (item.Error != "") != want[i].err {
+			t.Errorf("%s: error = %q, want error %v", item.Link, item.Error, want[i].err)
+			continue
+		}
+		if got := gjson.GetBytes(item.Outbound, "alter_id").Int(); got != want[i].alterID {
+			t.Errorf("%s: alter_id = %d, want %d", item.Link, got, want[i].alterID)
+		}
+	}
+}
+
+// convertClashForTest 以 taken 中的出站标签已存在、没有规则集和 route.final 的目录为前提转换 content
+func convertClashForTest(t *testing.T, content string, taken ...string) *clashConversion {
+	t.Helper()
+	outbounds := make(map[string]bool)
+	for _, tag := range taken {
+		outbounds[tag] = true
+	}
+	result, err := convertClashConfig([]byte(content),
+		func(tag string) bool { return outbounds[tag] },
+		func(string) bool { return false },
+		false)
+	if err != nil {
+		t.Fatalf("convertClashConfig: %v", err)
+	}
+	return result
+}
+
+// clashOutboundTags 返回转换结果中各出站的 "类型:标签"
+func clashOutboundTags(result *clashConversion) string {
+	var tags []string
+	for _, raw := range result.Outbounds {
+		tags = append(tags, gjson.GetBytes(raw, "type").String()+":"+gjson.GetBytes(raw, "tag").String())
+	}
+	return strings.Join(tags, ",")
+}
+
+func clashIssueItems(result *clashConversion) string {
+	var items []string
+	for _, issue := range result.Unconverted {
+		items = append(items, issue.Item)
+	}
+	return strings.Join(items, ",")
+}
+
+func TestConvertClashGroups(t *testing.T) {
+	result := convertClashForTest(t, `proxies:
+  - {name: HK, type: trojan, server: h.example, port: 443, password: pw}
+  - {name: broken, type: unknown, server: x.example, port: 443}
+groups-comment: ignored
+proxy-groups:
+  - {name: Proxy, type: select, proxies: [Auto, Empty, HK, REJECT, DIRECT]}
+  - {name: Auto, type: fallback, proxies: [HK], url: "https://cp.example/204", interval: 300}
+  - {name: Empty, type: select, proxies: [Nested]}
+  - {name: Nested, type: select, proxies: [broken]}
+`)
+	// Nested 只引用了转换失败的代理，去掉后 Empty 也随之为空
+	if got, want := clashOutboundTags(result), "selector:Proxy,urltest:Auto,trojan:HK,direct:direct"; got != want {
+		t.Errorf("outbounds = %q, want %q", got, want)
+	}
+	if result.Groups != 2 {
+		t.Errorf("groups = %d, want 2", result.Groups)
+	}
+	if got := gjson.GetBytes(result.Outbounds[0], "outbounds").Raw; got != `["Auto","HK","direct"]` {
+		t.Errorf("Proxy members = %s", got)
+	}
+	if got := gjson.GetBytes(result.Outbounds[1], "interval").String(); got != "300s" {
+		t.Errorf("Auto interval = %q, want 300s", got)
+	}
+	want := "groups-comment,broken,Auto,Nested,Empty,Proxy → Empty,Proxy → REJECT"
+	if got := clashIssueItems(result); got != want {
+		t.Errorf("unconverted = %q, want %q", got, want)
+	}
+}
+
+func TestConvertClashTagConflicts(t *testing.T) {
+	content := `proxies:
+  - {name: HK, type: trojan, server: h.example, port: 443, password: pw}
+  - {name: HK-2, type: trojan, server: h2.example, port: 443, password: pw}
+proxy-groups:
+  - {name: Proxy, type: select, proxies: [HK, HK-2]}
+rules:
+  - MATCH,DIRECT
+`
+	// HK 与目录中的出站冲突改名为 HK-2，随后的 HK-2 与之冲突再加后缀；已有 direct 时直接引用
+	result := convertClashForTest(t, content, "HK", "Proxy", "direct")
+	if got, want := clashOutboundTags(result), "selector:Proxy-2,trojan:HK-2,trojan:HK-2-2"; got != want {
+		t.Errorf("outbounds = %q, want %q", got, want)
+	}
+	if got := gjson.GetBytes(result.Outbounds[0], "outbounds").Raw; got != `["HK-2","HK-2-2"]` {
+		t.Errorf("Proxy members = %s", got)
+	}
+	if result.Final != "direct" {
+		t.Errorf("final = %q, want direct", result.Final)
+	}
+
+	// 目录中没有 direct 时在代理之后新增一个
+	result = convertClashForTest(t, content, "direct-x")
+	if got, want := clashOutboundTags(result), "selector:Proxy,trojan:HK,trojan:HK-2,direct:direct"; got != want {
+		t.Errorf("outbounds = %q, want %q", got, want)
+	}
+}
+
+func TestConvertClashRules(t *testing.T) {
+	result := convertClashForTest(t, `proxies:
+  - {name: HK, type: trojan, server: h.example, port: 443, password: pw}
+rule-providers:
+  binary: {type: http, behavior: domain, url: "https://r.example/binary.srs"}
+  classical: {type: http, behavior: classical, url: "https://r.example/classical.yaml"}
+rules:
+  - DOMAIN-SUFFIX,a.com,HK
+  - DOMAIN-SUFFIX,b.com,HK
+  - DOMAIN-SUFFIX,c.com,DIRECT
+  - GEOIP,LAN,DIRECT
+  - GEOIP,private,DIRECT
+  - IP-CIDR,10.0.0.0/8,DIRECT,no-resolve
+  - IP-CIDR6,fc00::/7,DIRECT
+  - DST-PORT,1000-2000,REJECT-DROP
+  - DST-PORT,443,REJECT
+  - GEOIP,CN,DIRECT
+  - GEOSITE,cn,DIRECT
+  - RULE-SET,binary,HK
+  - RULE-SET,classical,HK
+  - DOMAIN,x.com,Missing
+  - SCRIPT,foo,HK
+  - MATCH,HK
+`)
+	rules, err := json.Marshal(result.Rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"domain_suffix":["a.com","b.com"],"outbound":"HK"},` +
+		`{"domain_suffix":"c.com","outbound":"direct"},` +
+		`{"ip_is_private":true,"outbound":"direct"},` +
+		`{"ip_is_private":true,"outbound":"direct"},` +
+		`{"ip_cidr":["10.0.0.0/8","fc00::/7"],"outbound":"direct"},` +
+		`{"port_range":"1000:2000","action":"reject","method":"drop"},` +
+		`{"port":443,"action":"reject"},` +
+		`{"rule_set":["geoip-cn","geosite-cn"],"outbound":"direct"},` +
+		`{"rule_set":"binary","outbound":"HK"}]`
+	if got := string(rules); got != want {
+		t.Errorf("rules =\n%s\nwant\n%s", got, want)
+	}
+	var sets []string
+	for _, entry := range result.RuleSets {
+		sets = append(sets, entry.Tag+"="+entry.Format)
+	}
+	if got := strings.Join(sets, ","); got != "geoip-cn=binary,geosite-cn=binary,binary=binary" {
+		t.Errorf("rule sets = %q", got)
+	}
+	if result.Final != "HK" {
+		t.Errorf("final = %q, want HK", result.Final)
+	}
+	want = "SCRIPT,foo,HK,RULE-SET,classical,HK,DOMAIN,x.com,Missing"
+	if got := clashIssueItems(result); got != want {
+		t.Errorf("unconverted = %q, want %q", got, want)
+	}
+
+	// 目录中已有 route.final 时不转换 MATCH
+	result, err = convertClashConfig([]byte("proxies:\n  - {name: HK, type: trojan, server: h.example, port: 443, password: pw}\nrules:\n  - MATCH,HK\n"),
+		func(string) bool { return false }, func(string) bool { return false }, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Final != "" || clashIssueItems(result) != "MATCH,HK" {
+		t.Errorf("final = %q, unconverted = %q", result.Final, clashIssueItems(result))
+	}
+}
+
+func TestWriteNewConfigFilesRollback(t *testing.T) {
+	dir := useTestConfigDir(t, map[string]string{"60_blocker": "not a directory"})
+	paths := map[string]string{
+		"50_out.json":   filepath.Join(dir, "50_out.json"),
+		"51_route.json": filepath.Join(dir, "60_blocker", "51_route.json"),
+	}
+	contents := map[string][]byte{
+		"50_out.json":   []byte(`{"outbounds": []}`),
+		"51_route.json": []byte(`{"route": {}}`),
+	}
+	if _, err := writeNewConfigFiles(paths, contents); err == nil || !strings.Contains(err.Error(), "51_route.json") {
+		t.Fatalf("err = %v, want failure writing 51_route.json", err)
+	}
+	// 先写入的文件被删除
+	if _, err := os.Stat(paths["50_out.json"]); !os.IsNotExist(err) {
+		t.Errorf("50_out.json still exists after rollback: %v", err)
+	}
+
+	paths["51_route.json"] = filepath.Join(dir, "51_route.json")
+	written, err := writeNewConfigFiles(paths, contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(written, ","); got != "50_out.json,51_route.json" {
+		t.Errorf("written = %q", got)
+	}
+	for name, content := range contents {
+		if data, err := os.ReadFile(paths[name]); err != nil || string(data) != string(content) {
+			t.Errorf("%s = %q, %v", name, data, err)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// clientRuleSetURLs 客户端规则模板使用的远程规则集，来自 SagerNet 的 sing-geosite / sing-geoip
+var clientRuleSetURLs = map[string]string{
+	"geosite-cn":               "https://raw.githubusercontent.com/SagerNet/sing-geosite/rule-set/geosite-cn.srs",
+	"geosite-geolocation-!cn":  "https://raw.githubusercontent.com/SagerNet/sing-geosite/rule-set/geosite-geolocation-!cn.srs",
+	"geoip-cn":                 "https://raw.githubusercontent.com/SagerNet/sing-geoip/rule-set/geoip-cn.srs",
+	"geosite-category-ads-all": "https://raw.githubusercontent.com/SagerNet/sing-geosite/rule-set/geosite-category-ads-all.srs",
+}
+
+// clientRuleTemplate 生成客户端配置时可选的分流规则
+type clientRuleTemplate struct {
+	Key      string
+	Name     string
+	RuleSets []string // 用到的 clientRuleSetURLs 中的规则集
+	Rules    string   // 追加在基础规则之后的路由规则（JSON 数组）
+	DNSRules string   // DNS 规则（JSON 数组）
+	Final    string   // 未命中规则时的出站：proxy 或 direct
+}
+
+// clientRuleTemplates 按显示顺序排列，第一个为默认模板
+var clientRuleTemplates = []clientRuleTemplate{
+	{
+		Key:      "global",
+		Name:     "全局代理",
+		Rules:    `[]`,
+		DNSRules: `[]`,
+		Final:    "proxy",
+	},
+	{
+		Key:      "bypass_cn",
+		Name:     "绕过大陆",
+		RuleSets: []string{"geosite-cn", "geoip-cn"},
+		Rules:    `[{"rule_set": ["geosite-cn", "geoip-cn"], "outbound": "direct"}]`,
+		DNSRules: `[{"rule_set": "geosite-cn", "server": "local"}]`,
+		Final:    "proxy",
+	},
+	{
+		Key:      "bypass_cn_ads",
+		Name:     "绕过大陆并屏蔽广告",
+		RuleSets: []string{"geosite-category-ads-all", "geosite-cn", "geoip-cn"},
+		Rules:    `[{"rule_set": "geosite-category-ads-all", "action": "reject"}, {"rule_set": ["geosite-cn", "geoip-cn"], "outbound": "direct"}]`,
+		DNSRules: `[{"rule_set": "geosite-category-ads-all", "action": "predefined", "rcode": "NXDOMAIN"}, {"rule_set": "geosite-cn", "server": "local"}]`,
+		Final:    "proxy",
+	},
+	{
+		Key:      "proxy_foreign",
+		Name:     "仅代理境外网站",
+		RuleSets: []string{"geosite-geolocation-!cn"},
+		Rules:    `[{"rule_set": "geosite-geolocation-!cn", "outbound": "proxy"}]`,
+		DNSRules: `[{"rule_set": "geosite-geolocation-!cn", "server": "remote"}]`,
+		Final:    "direct",
+	},
+}
+
+// findClientRuleTemplate 按键查找规则模板，键为空时返回默认模板
+func findClientRuleTemplate(key string) (clientRuleTemplate, bool) {
+	if key == "" {
+		return clientRuleTemplates[0], true
+	}
+	for _, t := range clientRuleTemplates {
+		if t.Key == key {
+			return t, true
+		}
+	}
+	return clientRuleTemplate{}, false
+}
+
+// clientInbounds 客户端本地入站：tun 接管系统流量，mixed 提供本地 HTTP / SOCKS 代理
+var clientInbounds = map[string]string{
+	"tun": `{
+  "type": "tun",
+  "tag": "tun-in",
+  "address": ["172.19.0.1/30", "fdfe:dcba:9876::1/126"],
+  "auto_route": true,
+  "strict_route": true,
+  "stack": "mixed"
+}`,
+	"mixed": `{
+  "type": "mixed",
+  "tag": "mixed-in",
+  "listen": "127.0.0.1",
+  "listen_port": 2080
+}`,
+}
+
+// ClientDNS ...
+type ClientDNS struct {
+	Servers []json.RawMessage `json:"servers"`
+	Rules   json.RawMessage   `json:"rules"`
+	Final   string            `json:"final"`
+}
+
+// ClientRoute ...
+type ClientRoute struct {
+	Rules                 []json.RawMessage `json:"rules"`
+	RuleSet               []RuleSetEntry    `json:"rule_set,omitempty"`
+	Final                 string            `json:"final"`
+	AutoDetectInterface   bool              `json:"auto_detect_interface"`
+	DefaultDomainResolver string            `json:"default_domain_resolver"`
+}
+
+// RuleSetEntry 客户端配置中的远程规则集
+type RuleSetEntry struct {
+	Type           string `json:"type"`
+	Tag            string `json:"tag"`
+	Format         string `json:"format"`
+	URL            string `json:"url"`
+	DownloadDetour string `json:"download_detour,omitempty"`
+}
+
+// ClientConfig 生成的客户端完整配置，字段顺序即输出顺序
+type ClientConfig struct {
+	Log          json.RawMessage   `json:"log"`
+	DNS          ClientDNS         `json:"dns"`
+	Inbounds     []json.RawMessage `json:"inbounds"`
+	Outbounds    []interface{}     `json:"outbounds"`
+	Route        ClientRoute       `json:"route"`
+	Experimental json.RawMessage   `json:"experimental"`
+}
+
+// buildClientConfig 用客户端出站（标签改为 proxy）、本地入站和规则模板组装完整的客户端配置
+func buildClientConfig(outbound interface{}, inboundType string, template clientRuleTemplate) (*ClientConfig, error) {
+	inbound, ok := clientInbounds[inboundType]
+	if !ok {
+		return nil, fmt.Errorf("客户端入站只能是 tun 或 mixed")
+	}
+	raw, err := json.Marshal(outbound)
+	if err != nil {
+		return nil, err
+	}
+	if raw, err = jsoncSetRaw(raw, "tag", jsonQuote("proxy")); err != nil {
+		return nil, err
+	}
+	config := &ClientConfig{
+		Log: json.RawMessage(`{"level": "warn", "timestamp": true}`),
+		DNS: ClientDNS{
+			Servers: []json.RawMessage{
+				json.RawMessage(`{"type": "tls", "tag": "remote", "server": "8.8.8.8", "detour": "proxy"}`),
+				json.RawMessage(`{"type": "local", "tag": "local"}`),
+			},
+			Rules: json.RawMessage(template.DNSRules),
+			Final: "remote",
+		},
+		Inbounds:  []json.RawMessage{json.RawMessage(inbound)},
+		Outbounds: []interface{}{json.RawMessage(raw), json.RawMessage(`{"type": "direct", "tag": "direct"}`)},
+		Route: ClientRoute{
+			Rules: []json.RawMessage{
+				json.RawMessage(`{"action": "sniff"}`),
+				json.RawMessage(`{"protocol": "dns", "action": "hijack-dns"}`),
+				json.RawMessage(`{"ip_is_private": true, "outbound": "direct"}`),
+			},
+			Final:                 template.Final,
+			AutoDetectInterface:   true,
+			DefaultDomainResolver: "local",
+		},
+		Experimental: json.RawMessage(`{"cache_file": {"enabled": true}}`),
+	}
+	if template.Final == "direct" {
+		// 只代理部分网站时，其余域名也用本地 DNS 解析
+		config.DNS.Final = "local"
+	}
+	var rules []json.RawMessage
+	if err := json.Unmarshal([]byte(template.Rules), &rules); err != nil {
+		return nil, fmt.Errorf("规则模板 '%s' 格式错误: %v", template.Key, err)
+	}
+	config.Route.Rules = append(config.Route.Rules, rules...)
+	for _, tag := range template.RuleSets {
+		config.Route.RuleSet = append(config.Route.RuleSet, RuleSetEntry{
+			Type:           "remote",
+			Tag:            tag,
+			Format:         "binary",
+			URL:            clientRuleSetURLs[tag],
+			DownloadDetour: "proxy",
+		})
+	}
+	return config, nil
+}
+
+// selectClientUser 按用户名或下标选择入站用户；入站只有一个用户时可以省略
+func selectClientUser(clients []ClientOutbound, user string) (ClientOutbound, error) {
+	if user == "" {
+		if len(clients) == 1 {
+			return clients[0], nil
+		}
+		var names []string
+		for i, c := range clients {
+			if c.User != "" {
+				names = append(names, c.User)
+			} else {
+				names = append(names, strconv.Itoa(i))
+			}
+		}
+		return ClientOutbound{}, fmt.Errorf("入站有多个用户，请指定用户: %s", strings.Join(names, ", "))
+	}
+	for _, c := range clients {
+		if c.User == user {
+			return c, nil
+		}
+	}
+	if i, err := strconv.Atoi(user); err == nil && i >= 0 && i < len(clients) {
+		return clients[i], nil
+	}
+	return ClientOutbound{}, fmt.Errorf("用户 '%s' 不存在", user)
+}
+
+// ClientTemplateInfo ...
+type ClientTemplateInfo struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+// clientTemplatesHandler 处理 /api/client_templates 请求，按显示顺序列出客户端配置的规则模板
+func clientTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	templates := []ClientTemplateInfo{}
+	for _, t := range clientRuleTemplates {
+		templates = append(templates, ClientTemplateInfo{Key: t.Key, Name: t.Name})
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(map[string]interface{}{"templates": templates})
+}
+
+// clientConfigHandler 处理 /api/client_config 请求：为入站 tag 的某个用户生成完整的 sing-box 客户端配置。
+// 参数：user（用户名或下标）、server、insecure 同 share_links；inbound 为 tun（默认）或 mixed；
+// template 为规则模板；download=1 时作为附件下载。
+func clientConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	tag := query.Get("tag")
+	if tag == "" {
+		writeJSONError(w, "缺少入站标签", http.StatusBadRequest)
+		return
+	}
+	template, ok := findClientRuleTemplate(query.Get("template"))
+	if !ok {
+		writeJSONError(w, fmt.Sprintf("规则模板 '%s' 不存在", query.Get("template")), http.StatusBadRequest)
+		return
+	}
+	inboundType := query.Get("inbound")
+	if inboundType == "" {
+		inboundType = "tun"
+	}
+	server, err := shareServerAddress(r)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, inbound, err := findInbound(tag)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	clients, err := clientOutbounds(inbound, server, truthy(query.Get("insecure")))
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	client, err := selectClientUser(clients, query.Get("user"))
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	config, err := buildClientConfig(client.Outbound, inboundType, template)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	content, err := json.MarshalIndent(config, "", formatIndentString(getEditorConfig().Format.Indent))
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if truthy(query.Get("download")) {
+		name := tag
+		if client.User != "" {
+			name += "-" + client.User
+		}
+		name += ".json"
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(name)))
+	}
+	w.Write(append(content, '\n'))
+}
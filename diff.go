@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffOp 表示行级差异中的一行
+type diffOp struct {
+	Kind byte // ' ' 相同, '-' 删除, '+' 新增
+	Line string
+}
+
+// splitLines 按行切分文本，保留最后一行没有换行符的情况
+func splitLines(content []byte) []string {
+	text := string(content)
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// maxDiffEdits 逐行比较时允许的最大改动行数。Myers 算法回溯需要保存每一步的状态，
+// 内存与改动行数的平方成正比，超过上限时不再逐行比较
+const maxDiffEdits = 1000
+
+// diffLines 使用 Myers 算法计算 a -> b 的最短行级编辑序列。
+// 改动超过 maxDiffEdits 行时返回 false。
+func diffLines(a, b []string) ([]diffOp, bool) {
+	// 相同的开头和结尾不参与计算，通常只剩很少几行
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{Kind: ' ', Line: line})
+	}
+	middle, ok := myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	if !ok {
+		return nil, false
+	}
+	ops = append(ops, middle...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{Kind: ' ', Line: line})
+	}
+	return ops, true
+}
+
+// myersDiff 是 diffLines 的核心。第 d 步只会用到对角线 -d-1..d+1，回溯时也只保存这一段
+func myersDiff(a, b []string) ([]diffOp, bool) {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil, true
+	}
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= max && !found; d++ {
+		if d > maxDiffEdits {
+			return nil, false
+		}
+		snapshot := make([]int, 2*d+3)
+		copy(snapshot, v[offset-d-1:offset+d+2])
+		trace = append(trace, snapshot)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	// 回溯得到编辑序列，trace[d][i] 对应对角线 i-d-1
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		vd := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && vd[k+d] < vd[k+d+2]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := vd[prevK+d+1]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{Kind: ' ', Line: a[x]})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				ops = append(ops, diffOp{Kind: '+', Line: b[y]})
+			} else {
+				x--
+				ops = append(ops, diffOp{Kind: '-', Line: a[x]})
+			}
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops, true
+}
+
+// unifiedDiff 生成 unified 格式的差异文本 (3 行上下文)，内容相同时返回空字符串
+func unifiedDiff(aName, bName string, a, b []byte) string {
+	const context = 3
+	ops, ok := diffLines(splitLines(a), splitLines(b))
+	if !ok {
+		return fmt.Sprintf("--- %s\n+++ %s\n文件差异过大（超过 %d 行改动），不逐行显示\n", aName, bName, maxDiffEdits)
+	}
+
+	changed := false
+	for _, op := range ops {
+		if op.Kind != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == ' ' {
+			i++
+			continue
+		}
+		// 确定 hunk 范围：向前扩展上下文，向后合并相距不超过 2*context 的变更
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].Kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].Kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end += context
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = run
+		}
+
+		// 计算 hunk 头的行号
+		aStart, bStart := 1, 1
+		for _, op := range ops[:start] {
+			if op.Kind != '+' {
+				aStart++
+			}
+			if op.Kind != '-' {
+				bStart++
+			}
+		}
+		aCount, bCount := 0, 0
+		for _, op := range ops[start:end] {
+			if op.Kind != '+' {
+				aCount++
+			}
+			if op.Kind != '-' {
+				bCount++
+			}
+		}
+		if aCount == 0 {
+			aStart--
+		}
+		if bCount == 0 {
+			bStart--
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+		for _, op := range ops[start:end] {
+			sb.WriteByte(op.Kind)
+			sb.WriteString(op.Line)
+			sb.WriteByte('\n')
+		}
+		i = end
+	}
+	return sb.String()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiffLinesMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, rng.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(3)))
+		}
+		return lines
+	}
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(), randomLines()
+		ops, ok := diffLines(a, b)
+		if !ok {
+			t.Fatalf("diffLines(%q, %q) gave up", a, b)
+		}
+		var gotA, gotB []string
+		edits := 0
+		for _, op := range ops {
+			if op.Kind != '+' {
+				gotA = append(gotA, op.Line)
+			}
+			if op.Kind != '-' {
+				gotB = append(gotB, op.Line)
+			}
+			if op.Kind != ' ' {
+				edits++
+			}
+		}
+		if strings.Join(gotA, ",") != strings.Join(a, ",") || strings.Join(gotB, ",") != strings.Join(b, ",") {
+			t.Fatalf("diffLines(%q, %q) = %v does not reproduce the inputs", a, b, ops)
+		}
+		if want := len(a) + len(b) - 2*lcsLength(a, b); edits != want {
+			t.Fatalf("diffLines(%q, %q) uses %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+// lcsLength 用动态规划求最长公共子序列的长度，作为最短编辑序列的参照
+func lcsLength(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else if dp[i+1][j] > dp[i][j+1] {
+				dp[i][j] = dp[i+1][j]
+			} else {
+				dp[i][j] = dp[i][j+1]
+			}
+		}
+	}
+	return dp[0][0]
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	var a, b []string
+	for i := 1; i <= 10; i++ {
+		a = append(a, strconv.Itoa(i))
+		b = append(b, strconv.Itoa(i))
+	}
+	b[4] = "five"
+	want := "--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n"
+	if got := unifiedDiff("a", "b", []byte(strings.Join(a, "\n")), []byte(strings.Join(b, "\n"))); got != want {
+		t.Errorf("unifiedDiff =\n%s\nwant\n%s", got, want)
+	}
+	if got := unifiedDiff("a", "b", []byte("x\n"), []byte("x\n")); got != "" {
+		t.Errorf("unifiedDiff of equal content = %q", got)
+	}
+
+	// 改动过多时不逐行比较
+	var big, other []string
+	for i := 0; i < 2*maxDiffEdits; i++ {
+		big = append(big, "a"+strconv.Itoa(i))
+		other = append(other, "b"+strconv.Itoa(i))
+	}
+	got := unifiedDiff("a", "b", []byte(strings.Join(big, "\n")), []byte(strings.Join(other, "\n")))
+	if !strings.HasPrefix(got, "--- a\n+++ b\n") || strings.Contains(got, "@@") {
+		t.Errorf("unifiedDiff of large change = %q", got)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DEFAULT_EDITOR_CONFIG_PATH 编辑器自身配置文件（账户等）的默认位置
+const DEFAULT_EDITOR_CONFIG_PATH = "/etc/sb_editor/config.json"
+
+// EditorUser 登录账户，密码只保存 bcrypt 哈希
+type EditorUser struct {
+	Username     string `json:"username"`
+	PasswordHash string `json:"password_hash"`
+}
+
+// EditorConfig 编辑器自身的配置，与 Sing-box 配置无关
+type EditorConfig struct {
+	Users             []EditorUser         `json:"users"`
+	SessionTTLMinutes int                  `json:"session_ttl_minutes,omitempty"` // 会话有效期，默认 720 分钟
+	Backup            BackupPolicy         `json:"backup"`
+	SafeRestart       SafeRestartConfig    `json:"safe_restart"`
+	Git               GitHistoryConfig     `json:"git"`
+	Format            FormatConfig         `json:"format"`
+	Share             ShareConfig          `json:"share"`
+	Subscriptions     []SubscriptionConfig `json:"subscriptions,omitempty"`
+}
+
+// BackupPolicy 保存前自动备份的保留策略，0 表示使用默认值
+type BackupPolicy struct {
+	KeepCount int `json:"keep_count,omitempty"` // 每个文件最多保留的备份数，默认 50
+	KeepDays  int `json:"keep_days,omitempty"`  // 备份最长保留天数，默认 30
+}
+
+// SafeRestartConfig 安全重启的健康检查参数
+type SafeRestartConfig struct {
+	GraceSeconds int    `json:"grace_seconds,omitempty"` // 重启后观察服务是否保持运行的时长，默认 10 秒
+	ProbeAddress string `json:"probe_address,omitempty"` // 可选，观察期结束时 TCP 探测的入站地址，如 127.0.0.1:443
+}
+
+// GitHistoryConfig 用本地 git 仓库记录配置目录的版本历史
+type GitHistoryConfig struct {
+	Enabled bool `json:"enabled"` // 启用后每次保存都会在配置目录的 git 仓库中产生一个提交
+}
+
+// FormatConfig 保存时的自动排版设置
+type FormatConfig struct {
+	OnSave bool   `json:"on_save"`          // 启用后每次保存都按统一风格重新排版整个文件（保留注释）
+	Indent string `json:"indent,omitempty"` // 缩进风格："tab" 或空格数，默认 2
+}
+
+// ShareConfig 从入站生成客户端分享链接的设置
+type ShareConfig struct {
+	Server string `json:"server,omitempty"` // 客户端连接使用的服务器地址（域名或公网 IP），请求中未指定时使用
+}
+
+// 全局变量存储编辑器配置及其文件路径
+var (
+	editorConfig      EditorConfig
+	editorConfigPath  string
+	editorConfigMutex sync.RWMutex
+)
+
+// loadEditorConfig 从指定路径读取编辑器配置，文件不存在时使用空配置
+func loadEditorConfig(path string) error {
+	editorConfigMutex.Lock()
+	defer editorConfigMutex.Unlock()
+
+	editorConfigPath = path
+	editorConfig = EditorConfig{}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Printf("编辑器配置文件 '%s' 不存在，将使用默认配置。", path)
+			return nil
+		}
+		return fmt.Errorf("无法读取编辑器配置文件 '%s': %v", path, err)
+	}
+	if err := json.Unmarshal(content, &editorConfig); err != nil {
+		return fmt.Errorf("编辑器配置文件 '%s' 格式错误: %v", path, err)
+	}
+	return nil
+}
+
+// saveEditorConfigLocked 将编辑器配置写回磁盘，调用方必须持有 editorConfigMutex 写锁。
+// 文件中包含密码哈希，因此权限固定为 0600。
+func saveEditorConfigLocked() error {
+	if err := os.MkdirAll(filepath.Dir(editorConfigPath), 0700); err != nil {
+		return fmt.Errorf("无法创建编辑器配置目录: %v", err)
+	}
+	content, err := json.MarshalIndent(editorConfig, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(editorConfigPath, content, 0600); err != nil {
+		return fmt.Errorf("无法写入编辑器配置文件 '%s': %v", editorConfigPath, err)
+	}
+	return nil
+}
+
+// getEditorConfig 返回当前编辑器配置的副本
+func getEditorConfig() EditorConfig {
+	editorConfigMutex.RLock()
+	defer editorConfigMutex.RUnlock()
+	cfg := editorConfig
+	cfg.Users = append([]EditorUser(nil), editorConfig.Users...)
+	cfg.Subscriptions = append([]SubscriptionConfig(nil), editorConfig.Subscriptions...)
+	return cfg
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recentVersionLimit 最多缓存多少个最近下发给客户端的文件版本，用于冲突时生成差异
+const recentVersionLimit = 64
+
+// 最近下发的文件版本缓存：内容哈希 -> 内容
+var (
+	recentVersions      = make(map[string][]byte)
+	recentVersionsOrder []string
+	recentVersionsMutex sync.Mutex
+)
+
+// configWriteMutex 串行化所有对配置目录的“读取-校验-写入”操作
+var configWriteMutex sync.Mutex
+
+// contentHash 返回内容的短哈希
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:8])
+}
+
+// computeETag 由内容哈希和修改时间生成 ETag
+func computeETag(content []byte, modTime time.Time) string {
+	return fmt.Sprintf(`"%s-%s"`, contentHash(content), strconv.FormatInt(modTime.UnixNano(), 36))
+}
+
+// etagHash 从 ETag 中取出内容哈希部分
+func etagHash(etag string) string {
+	etag = strings.Trim(strings.TrimPrefix(strings.TrimSpace(etag), "W/"), `"`)
+	if i := strings.Index(etag, "-"); i >= 0 {
+		return etag[:i]
+	}
+	return etag
+}
+
+// rememberVersion 缓存一个已下发给客户端的文件版本
+func rememberVersion(content []byte) {
+	hash := contentHash(content)
+	recentVersionsMutex.Lock()
+	defer recentVersionsMutex.Unlock()
+	if _, ok := recentVersions[hash]; ok {
+		return
+	}
+	recentVersions[hash] = append([]byte(nil), content...)
+	recentVersionsOrder = append(recentVersionsOrder, hash)
+	if len(recentVersionsOrder) > recentVersionLimit {
+		delete(recentVersions, recentVersionsOrder[0])
+		recentVersionsOrder = recentVersionsOrder[1:]
+	}
+}
+
+// lookupVersion 按 ETag 查找客户端当初拿到的文件内容
+func lookupVersion(etag string) ([]byte, bool) {
+	recentVersionsMutex.Lock()
+	defer recentVersionsMutex.Unlock()
+	content, ok := recentVersions[etagHash(etag)]
+	return content, ok
+}
+
+// ifMatchSatisfied 判断 If-Match 请求头是否与当前 ETag 匹配
+func ifMatchSatisfied(ifMatch, currentETag string) bool {
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == currentETag {
+			return true
+		}
+	}
+	return false
+}
+
+// readFileWithETag 读取文件内容并计算其 ETag
+func readFileWithETag(filePath string) ([]byte, string, error) {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	return content, computeETag(content, info.ModTime()), nil
+}
+
+// writeConflictResponse 返回 409 Conflict，并附上客户端基准版本到磁盘当前内容的差异
+func writeConflictResponse(w http.ResponseWriter, filename, ifMatch string, current []byte, currentETag string) {
+	resp := map[string]interface{}{
+		"error":        fmt.Sprintf("文件 '%s' 在你编辑期间已被修改（可能来自其他用户或编辑器之外），请先查看差异。", filename),
+		"current_etag": currentETag,
+	}
+	if base, ok := lookupVersion(ifMatch); ok {
+		resp["diff"] = unifiedDiff(filename+" (你加载的版本)", filename+" (磁盘上的当前版本)", base, current)
+	} else {
+		resp["diff"] = ""
+		resp["diff_unavailable"] = "服务器已无法找到你加载的版本，无法生成差异。"
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("ETag", currentETag)
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// isWithinDir 判断 path 是否位于 dir 之内（两者都应是绝对路径）
+func isWithinDir(dir, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveInsideConfigDir 解析 filePath 上的符号链接，
+// 若最终目标位于活动配置目录之外则拒绝，返回真实的目标路径。
+// 文件不存在时原样返回 filePath。
+func resolveInsideConfigDir(filePath string) (string, error) {
+	baseDir, err := activeConfigDir()
+	if err != nil {
+		return "", err
+	}
+	realBase, err := filepath.EvalSymlinks(baseDir)
+	if err != nil {
+		return "", fmt.Errorf("无法解析配置目录: %v", err)
+	}
+	resolved, err := filepath.EvalSymlinks(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return filePath, nil
+		}
+		return "", fmt.Errorf("无法解析文件路径: %v", err)
+	}
+	if !isWithinDir(realBase, resolved) {
+		return "", fmt.Errorf("拒绝访问：'%s' 是指向配置目录之外的符号链接", filepath.Base(filePath))
+	}
+	return resolved, nil
+}
+
+// writeConfigFile 以“写临时文件 -> fsync -> rename”的方式原子地写入配置文件。
+// 进程中途退出时原文件保持完整；已存在文件的权限、属主和属组保持不变；
+// 拒绝通过符号链接写到配置目录之外。
+func writeConfigFile(filePath string, content []byte) error {
+	return writeConfigFileMode(filePath, content, 0644)
+}
+
+// writeConfigFileMode 同 writeConfigFile，文件不存在时以 mode 权限创建。
+// 权限在 rename 之前就设置在临时文件上，新文件不会有权限过宽的时间窗口。
+func writeConfigFileMode(filePath string, content []byte, mode os.FileMode) error {
+	target, err := resolveInsideConfigDir(filePath)
+	if err != nil {
+		return err
+	}
+
+	uid, gid := -1, -1
+	if info, err := os.Stat(target); err == nil {
+		mode = info.Mode().Perm()
+		if st, ok := info.Sys().(*syscall.Stat_t); ok {
+			uid, gid = int(st.Uid), int(st.Gid)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	dir := filepath.Dir(target)
+	// 临时文件以 . 开头且不以 .json 结尾，sing-box 读取目录时会忽略它
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(target)+".tmp-")
+	if err != nil {
+		return fmt.Errorf("无法创建临时文件: %v", err)
+	}
+	tmpName := tmp.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("fsync 临时文件失败: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, mode); err != nil {
+		return fmt.Errorf("无法设置文件权限: %v", err)
+	}
+	if uid != -1 && (uid != os.Getuid() || gid != os.Getgid()) {
+		if err := os.Chown(tmpName, uid, gid); err != nil {
+			return fmt.Errorf("无法保留文件属主 (%d:%d): %v", uid, gid, err)
+		}
+	}
+	if err := os.Rename(tmpName, target); err != nil {
+		return fmt.Errorf("替换原文件失败: %v", err)
+	}
+	committed = true
+
+	// fsync 目录，确保 rename 本身也已落盘
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteConfigFileMode(t *testing.T) {
+	dir := useTestConfigDir(t, nil)
+
+	// 新文件使用指定的权限
+	secret := filepath.Join(dir, "secret.json")
+	if err := writeConfigFileMode(secret, []byte(`{}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	checkFileMode(t, secret, 0600)
+
+	// 已存在的文件保持原有权限
+	if err := writeConfigFile(secret, []byte(`{"log": {}}`)); err != nil {
+		t.Fatal(err)
+	}
+	checkFileMode(t, secret, 0600)
+
+	plain := filepath.Join(dir, "plain.json")
+	if err := writeConfigFile(plain, []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	checkFileMode(t, plain, 0644)
+}
+
+func checkFileMode(t *testing.T, path string, want os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != want {
+		t.Errorf("%s mode = %v, want %v", filepath.Base(path), got, want)
+	}
+}
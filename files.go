@@ -0,0 +1,446 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// configTemplates 新建文件时可选的模板，键与 configTypeMap 相同。
+// configTypeMap 中没有专门模板的根键使用空对象。
+var configTemplates = map[string]string{
+	"log": `{
+  "log": {
+    "level": "info",
+    "timestamp": true
+  }
+}
+`,
+	"experimental": `{
+  "experimental": {
+    "cache_file": {
+      "enabled": true
+    }
+  }
+}
+`,
+	"dns": `{
+  "dns": {
+    "servers": [
+      {
+        "type": "local",
+        "tag": "local"
+      }
+    ],
+    "rules": [],
+    "final": "local"
+  }
+}
+`,
+	"inbounds": `{
+  "inbounds": []
+}
+`,
+	"outbounds": `{
+  "outbounds": []
+}
+`,
+	"route": `{
+  "route": {
+    "rules": [],
+    "rule_set": []
+  }
+}
+`,
+	"ntp": `{
+  "ntp": {
+    "enabled": true,
+    "server": "time.apple.com",
+    "server_port": 123,
+    "interval": "30m"
+  }
+}
+`,
+}
+
+// configTemplate 返回某个根键的模板内容
+func configTemplate(key string) (string, bool) {
+	if _, ok := configTypeMap[key]; !ok {
+		return "", false
+	}
+	if content, ok := configTemplates[key]; ok {
+		return content, true
+	}
+	return fmt.Sprintf("{\n  %s: {}\n}\n", jsonQuote(key)), true
+}
+
+// FileTemplateInfo ...
+type FileTemplateInfo struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+// fileTemplatesHandler 处理 /api/file_templates 请求，按显示顺序列出可用的模板
+func fileTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	keys := make([]string, 0, len(configTypeMap))
+	for key := range configTypeMap {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return configTypeMap[keys[i]].Order < configTypeMap[keys[j]].Order })
+	templates := []FileTemplateInfo{}
+	for _, key := range keys {
+		templates = append(templates, FileTemplateInfo{Key: key, Name: configTypeMap[key].FunctionName})
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(map[string]interface{}{"templates": templates})
+}
+
+// writeFileOpError 目标已存在时返回 409，其余校验失败返回 403
+func writeFileOpError(w http.ResponseWriter, filename string, err error) {
+	if errors.Is(err, errFileExists) {
+		writeJSONError(w, fmt.Sprintf("文件 '%s' 已存在", filename), http.StatusConflict)
+		return
+	}
+	writeJSONError(w, err.Error(), http.StatusForbidden)
+}
+
+// CreateFileRequest ...
+type CreateFileRequest struct {
+	Filename string `json:"filename"`
+	Template string `json:"template,omitempty"` // configTypeMap 中的根键，为空时创建空对象
+	Content  string `json:"content,omitempty"`  // 直接指定内容，优先于模板
+}
+
+// createFileHandler 处理 /api/create_file 请求，在配置目录中新建一个 .json 文件
+func createFileHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	var req CreateFileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "无效的请求体", http.StatusBadRequest)
+		return
+	}
+	content := []byte("{}\n")
+	if req.Content != "" {
+		content = []byte(req.Content)
+	} else if req.Template != "" {
+		tmpl, ok := configTemplate(req.Template)
+		if !ok {
+			writeJSONError(w, fmt.Sprintf("没有名为 '%s' 的模板", req.Template), http.StatusBadRequest)
+			return
+		}
+		content = []byte(tmpl)
+	}
+	if err := validateConfigFile(content); err != nil {
+		writeSyntaxError(w, "文件", err.(*JSONSyntaxError))
+		return
+	}
+
+	configWriteMutex.Lock()
+	defer configWriteMutex.Unlock()
+
+	filePath, err := validateNewFilename(req.Filename)
+	if err != nil {
+		writeFileOpError(w, req.Filename, err)
+		return
+	}
+	if err := ensureParentDir(filePath); err != nil {
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := writeConfigFile(filePath, content); err != nil {
+		writeJSONError(w, fmt.Sprintf("创建文件失败：%v", err), http.StatusInternalServerError)
+		return
+	}
+	recordHistory(req.Filename, sessionUser(r), "新建文件")
+	log.Printf("用户 '%s' 新建了文件 %s", sessionUser(r), req.Filename)
+	writeJSONResponse(w, "success", fmt.Sprintf("已创建 '%s'。", req.Filename), http.StatusOK)
+}
+
+// FileRequest 重命名、复制、删除文件的请求
+type FileRequest struct {
+	Filename    string `json:"filename"`
+	NewFilename string `json:"new_filename,omitempty"` // 重命名或复制的目标文件名
+}
+
+// decodeFileRequest 解析请求并校验源文件和（需要时）目标文件名
+func decodeFileRequest(w http.ResponseWriter, r *http.Request, needTarget bool) (req FileRequest, src, dst string, ok bool) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "无效的请求体", http.StatusBadRequest)
+		return
+	}
+	src, err := validateFilename(req.Filename)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if needTarget {
+		if dst, err = validateNewFilename(req.NewFilename); err != nil {
+			writeFileOpError(w, req.NewFilename, err)
+			return
+		}
+	}
+	return req, src, dst, true
+}
+
+// renameFileHandler 处理 /api/rename_file 请求。文件的备份随文件一起改名。
+// 注意文件名决定了 sing-box 的合并顺序。
+func renameFileHandler(w http.ResponseWriter, r *http.Request) {
+	configWriteMutex.Lock()
+	defer configWriteMutex.Unlock()
+
+	req, src, dst, ok := decodeFileRequest(w, r, true)
+	if !ok {
+		return
+	}
+	if err := ensureParentDir(dst); err != nil {
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := os.Rename(src, dst); err != nil {
+		writeJSONError(w, fmt.Sprintf("重命名失败：%v", err), http.StatusInternalServerError)
+		return
+	}
+	// 备份目录跟着改名，目标已有备份（如曾经删除过同名文件）时保留原处
+	if oldDir, err := backupDirFor(req.Filename); err == nil {
+		newDir, _ := backupDirFor(req.NewFilename)
+		if _, err := os.Stat(oldDir); err == nil {
+			if _, err := os.Stat(newDir); os.IsNotExist(err) {
+				os.MkdirAll(filepath.Dir(newDir), 0700)
+				if err := os.Rename(oldDir, newDir); err != nil {
+					log.Printf("无法移动 %s 的备份: %v", req.Filename, err)
+				}
+			}
+		}
+	}
+	username := sessionUser(r)
+	if historyEnabled() {
+		if err := commitHistory([]string{req.Filename, req.NewFilename}, username, fmt.Sprintf("重命名文件 %s -> %s", req.Filename, req.NewFilename)); err != nil {
+			log.Printf("版本历史: %v", err)
+		}
+	}
+	log.Printf("用户 '%s' 将文件 %s 重命名为 %s", username, req.Filename, req.NewFilename)
+	writeJSONResponse(w, "success", fmt.Sprintf("已将 '%s' 重命名为 '%s'。", req.Filename, req.NewFilename), http.StatusOK)
+}
+
+// duplicateFileHandler 处理 /api/duplicate_file 请求，副本保留原文件的权限
+func duplicateFileHandler(w http.ResponseWriter, r *http.Request) {
+	configWriteMutex.Lock()
+	defer configWriteMutex.Unlock()
+
+	req, src, dst, ok := decodeFileRequest(w, r, true)
+	if !ok {
+		return
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		writeJSONError(w, fmt.Sprintf("无法读取 '%s': %v", req.Filename, err), http.StatusInternalServerError)
+		return
+	}
+	content, err := ioutil.ReadFile(src)
+	if err != nil {
+		writeJSONError(w, fmt.Sprintf("无法读取 '%s': %v", req.Filename, err), http.StatusInternalServerError)
+		return
+	}
+	if err := ensureParentDir(dst); err != nil {
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := writeConfigFileMode(dst, content, info.Mode().Perm()); err != nil {
+		writeJSONError(w, fmt.Sprintf("复制文件失败：%v", err), http.StatusInternalServerError)
+		return
+	}
+	recordHistory(req.NewFilename, sessionUser(r), fmt.Sprintf("复制自 %s", req.Filename))
+	log.Printf("用户 '%s' 将文件 %s 复制为 %s", sessionUser(r), req.Filename, req.NewFilename)
+	message := fmt.Sprintf("已将 '%s' 复制为 '%s'。", req.Filename, req.NewFilename)
+	// 两个文件都在根目录时都会被 sing-box 读取，其中的标签必然重复
+	if !strings.Contains(req.Filename, "/") && !strings.Contains(req.NewFilename, "/") {
+		message += "副本中的标签与原文件重复，重启服务前请修改或移走其中一个。"
+	}
+	writeJSONResponse(w, "success", message, http.StatusOK)
+}
+
+// trashDir 返回回收站目录: <配置目录>/.sb_editor/trash/，每个被删除的文件放在以删除时间命名的子目录中
+func trashDir() (string, error) {
+	baseDir, err := activeConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(baseDir, editorDataDirName, "trash"), nil
+}
+
+// deleteFileHandler 处理 /api/delete_file 请求。文件被移到回收站，可以通过 /api/restore_trash 恢复。
+func deleteFileHandler(w http.ResponseWriter, r *http.Request) {
+	configWriteMutex.Lock()
+	defer configWriteMutex.Unlock()
+
+	req, src, _, ok := decodeFileRequest(w, r, false)
+	if !ok {
+		return
+	}
+	dir, err := trashDir()
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	id := time.Now().Format(backupTimeFormat)
+	itemDir := filepath.Join(dir, id)
+	// 子目录中的文件在回收站中保留相对路径，恢复时回到原来的位置
+	target := filepath.Join(itemDir, filepath.FromSlash(req.Filename))
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		writeJSONError(w, fmt.Sprintf("无法创建回收站目录：%v", err), http.StatusInternalServerError)
+		return
+	}
+	if err := os.Rename(src, target); err != nil {
+		os.RemoveAll(itemDir)
+		writeJSONError(w, fmt.Sprintf("删除失败：%v", err), http.StatusInternalServerError)
+		return
+	}
+	recordHistory(req.Filename, sessionUser(r), "删除文件")
+	log.Printf("用户 '%s' 将文件 %s 移到了回收站 (%s)", sessionUser(r), req.Filename, id)
+	writeJSONResponse(w, "success", fmt.Sprintf("已将 '%s' 移到回收站。", req.Filename), http.StatusOK)
+}
+
+// TrashItem 回收站中的一个文件
+type TrashItem struct {
+	ID       string    `json:"id"`
+	Filename string    `json:"filename"`
+	Time     time.Time `json:"time"`
+	Size     int64     `json:"size"`
+}
+
+// readTrashItem 读取回收站中某一项
+func readTrashItem(dir, id string) (TrashItem, error) {
+	if !backupIDPattern.MatchString(id) {
+		return TrashItem{}, fmt.Errorf("非法的回收站 ID")
+	}
+	t, err := time.ParseInLocation(backupTimeFormat, id, time.Local)
+	if err != nil {
+		return TrashItem{}, err
+	}
+	itemDir := filepath.Join(dir, id)
+	item := TrashItem{ID: id, Time: t}
+	err = filepath.WalkDir(itemDir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(itemDir, p)
+		item.Filename = filepath.ToSlash(rel)
+		item.Size = info.Size()
+		return filepath.SkipAll
+	})
+	if err != nil {
+		return TrashItem{}, err
+	}
+	if item.Filename == "" {
+		return TrashItem{}, fmt.Errorf("回收站中的 %s 是空的", id)
+	}
+	return item, nil
+}
+
+// listTrashHandler 处理 /api/list_trash 请求，按删除时间倒序列出回收站中的文件
+func listTrashHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	dir, err := trashDir()
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	items := []TrashItem{}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		writeJSONError(w, fmt.Sprintf("无法读取回收站: %v", err), http.StatusInternalServerError)
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if item, err := readTrashItem(dir, entry.Name()); err == nil {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID > items[j].ID })
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+}
+
+// RestoreTrashRequest ...
+type RestoreTrashRequest struct {
+	ID          string `json:"id"`
+	NewFilename string `json:"new_filename,omitempty"` // 为空时恢复为原文件名
+}
+
+// restoreTrashHandler 处理 /api/restore_trash 请求，把回收站中的文件移回配置目录
+func restoreTrashHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	var req RestoreTrashRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "无效的请求体", http.StatusBadRequest)
+		return
+	}
+	dir, err := trashDir()
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	configWriteMutex.Lock()
+	defer configWriteMutex.Unlock()
+
+	item, err := readTrashItem(dir, req.ID)
+	if err != nil {
+		writeJSONError(w, fmt.Sprintf("无法读取回收站: %v", err), http.StatusNotFound)
+		return
+	}
+	filename := req.NewFilename
+	if filename == "" {
+		filename = item.Filename
+	}
+	dst, err := validateNewFilename(filename)
+	if err != nil {
+		writeFileOpError(w, filename, err)
+		return
+	}
+	if err := ensureParentDir(dst); err != nil {
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := os.Rename(filepath.Join(dir, item.ID, filepath.FromSlash(item.Filename)), dst); err != nil {
+		writeJSONError(w, fmt.Sprintf("恢复失败：%v", err), http.StatusInternalServerError)
+		return
+	}
+	os.RemoveAll(filepath.Join(dir, item.ID))
+	recordHistory(filename, sessionUser(r), "从回收站恢复")
+	log.Printf("用户 '%s' 从回收站恢复了 %s (%s)", sessionUser(r), filename, item.ID)
+	writeJSONResponse(w, "success", fmt.Sprintf("已恢复 '%s'。", filename), http.StatusOK)
+}
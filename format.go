@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultFormatIndent 未配置缩进风格时使用两个空格
+const defaultFormatIndent = "2"
+
+// JSONSyntaxError 描述提交内容中的语法错误位置，行列号从 1 开始
+type JSONSyntaxError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+	Snippet string `json:"snippet"` // 出错位置附近的几行，带行号和指示符
+}
+
+func (e *JSONSyntaxError) Error() string {
+	return fmt.Sprintf("第 %d 行第 %d 列: %s", e.Line, e.Column, e.Message)
+}
+
+// newJSONSyntaxError 根据字节偏移量计算行列号并截取附近的内容
+func newJSONSyntaxError(content []byte, offset int, message string) *JSONSyntaxError {
+	line, column := offsetPosition(content, offset)
+	return &JSONSyntaxError{
+		Line:    line,
+		Column:  column,
+		Message: message,
+		Snippet: errorSnippet(content, line, column),
+	}
+}
+
+// offsetPosition 把字节偏移量换算为行号和列号（列按字符计），都从 1 开始
+func offsetPosition(content []byte, offset int) (int, int) {
+	if offset > len(content) {
+		offset = len(content)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	line := bytes.Count(content[:offset], []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(content[:offset], '\n') + 1
+	return line, utf8.RuneCount(content[lineStart:offset]) + 1
+}
+
+// errorSnippet 返回出错行及其前后各两行，并在出错列下方画出 ^
+func errorSnippet(content []byte, line, column int) string {
+	lines := strings.Split(string(content), "\n")
+	first := line - 2
+	if first < 1 {
+		first = 1
+	}
+	last := line + 2
+	if last > len(lines) {
+		last = len(lines)
+	}
+	width := len(strconv.Itoa(last))
+	var b strings.Builder
+	for n := first; n <= last; n++ {
+		text := strings.TrimRight(lines[n-1], "\r")
+		fmt.Fprintf(&b, "%*d | %s\n", width, n, text)
+		if n == line {
+			fmt.Fprintf(&b, "%s | %s^\n", strings.Repeat(" ", width), caretPadding(text, column))
+		}
+	}
+	return b.String()
+}
+
+// caretPadding 生成指示符前的空白，制表符原样保留以便对齐
+func caretPadding(text string, column int) string {
+	var b strings.Builder
+	n := 1
+	for _, r := range text {
+		if n >= column {
+			break
+		}
+		if r == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+		n++
+	}
+	for ; n < column; n++ {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// writeSyntaxError 返回 400 响应，附带错误的行列号和附近内容。scope 说明出错的是哪部分内容。
+func writeSyntaxError(w http.ResponseWriter, scope string, syntaxErr *JSONSyntaxError) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":        fmt.Sprintf("%s JSON 格式错误，%v\n%s", scope, syntaxErr, syntaxErr.Snippet),
+		"syntax_error": syntaxErr,
+	})
+}
+
+// validateJSONC 校验 JSONC 文本（允许注释和尾随逗号）的语法，错误位置对应原文
+func validateJSONC(content []byte) error {
+	stripped := stripJSONC(content)
+	if len(bytes.TrimSpace(stripped)) == 0 {
+		return newJSONSyntaxError(content, len(content), "内容为空")
+	}
+	var value interface{}
+	if err := json.Unmarshal(stripped, &value); err != nil {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			// Offset 表示出错前已读取的字节数，出错的字符位于 Offset-1；
+			// 内容意外结束时指向最后一个非空白字符之后
+			offset := int(syntaxErr.Offset) - 1
+			if strings.HasPrefix(syntaxErr.Error(), "unexpected end") {
+				offset = len(bytes.TrimRight(stripped, " \t\r\n"))
+			}
+			return newJSONSyntaxError(content, offset, syntaxErr.Error())
+		}
+		return newJSONSyntaxError(content, 0, err.Error())
+	}
+	return nil
+}
+
+// validateConfigFile 校验整个配置文件：语法正确且根节点是对象
+func validateConfigFile(content []byte) error {
+	if err := validateJSONC(content); err != nil {
+		return err
+	}
+	if root := jsoncParse(content); !root.IsObject() {
+		return newJSONSyntaxError(content, root.Index, "配置文件的根节点必须是 JSON 对象")
+	}
+	return nil
+}
+
+// formatIndentString 把配置中的缩进风格（"tab" 或空格数）转换为实际的缩进字符串
+func formatIndentString(style string) string {
+	style = strings.TrimSpace(style)
+	if style == "" {
+		style = defaultFormatIndent
+	}
+	if strings.EqualFold(style, "tab") {
+		return "\t"
+	}
+	n, err := strconv.Atoi(style)
+	if err != nil || n < 0 || n > 8 {
+		n, _ = strconv.Atoi(defaultFormatIndent)
+	}
+	return strings.Repeat(" ", n)
+}
+
+// jsoncToken 是格式化时使用的词法单元
+type jsoncToken struct {
+	text          string
+	newlineBefore bool // 与上一个词法单元之间是否有换行
+}
+
+func (t jsoncToken) isComment() bool {
+	return strings.HasPrefix(t.text, "//") || strings.HasPrefix(t.text, "/*")
+}
+
+// tokenizeJSONC 把已通过校验的 JSONC 文本切分为词法单元，空白被丢弃
+func tokenizeJSONC(content []byte) []jsoncToken {
+	var tokens []jsoncToken
+	newline := false
+	for i := 0; i < len(content); {
+		c := content[i]
+		start := i
+		switch {
+		case c == '\n':
+			newline = true
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		case c == '"':
+			for i++; i < len(content) && content[i] != '"'; i++ {
+				if content[i] == '\\' {
+					i++
+				}
+			}
+			i++
+		case c == '/' && i+1 < len(content) && content[i+1] == '/':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			end := bytes.Index(content[i+2:], []byte("*/"))
+			if end < 0 {
+				i = len(content)
+			} else {
+				i += end + 4
+			}
+		case strings.IndexByte("{}[]:,", c) >= 0:
+			i++
+		default:
+			for i < len(content) && strings.IndexByte("{}[]:,\"/ \t\r\n", content[i]) < 0 {
+				i++
+			}
+		}
+		if i > len(content) {
+			i = len(content)
+		}
+		text := strings.TrimRight(string(content[start:i]), " \t\r")
+		tokens = append(tokens, jsoncToken{text: text, newlineBefore: newline})
+		newline = false
+	}
+	return tokens
+}
+
+// formatJSONC 按统一的缩进风格重新排版 JSONC 文本：对象成员和数组元素各占一行，
+// 注释保留在原来的相对位置（行尾注释仍留在行尾），尾随逗号被去掉。调用前内容必须已通过校验。
+func formatJSONC(content []byte, indent string) []byte {
+	tokens := tokenizeJSONC(content)
+	var out bytes.Buffer
+	depth := 0
+	pendingNewline := false
+
+	// nextSignificant 返回 i 之后第一个非注释词法单元的下标
+	nextSignificant := func(i int) int {
+		for j := i + 1; j < len(tokens); j++ {
+			if !tokens[j].isComment() {
+				return j
+			}
+		}
+		return len(tokens)
+	}
+	newLine := func() {
+		out.WriteByte('\n')
+		out.WriteString(strings.Repeat(indent, depth))
+	}
+	// separate 在同一行上追加内容前补一个空格
+	separate := func() {
+		if out.Len() == 0 {
+			return
+		}
+		if last := out.Bytes()[out.Len()-1]; last != ' ' && last != '\n' && last != '\t' {
+			out.WriteByte(' ')
+		}
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case tok.isComment():
+			if tok.newlineBefore && out.Len() > 0 {
+				newLine()
+			} else {
+				separate()
+			}
+			out.WriteString(tok.text)
+			if strings.HasPrefix(tok.text, "//") || tok.newlineBefore {
+				pendingNewline = true
+			}
+		case tok.text == "{" || tok.text == "[":
+			if pendingNewline {
+				newLine()
+			} else {
+				separate()
+			}
+			pendingNewline = false
+			closing := "}"
+			if tok.text == "[" {
+				closing = "]"
+			}
+			// 空对象/数组（中间没有注释）写成一行
+			if i+1 < len(tokens) && tokens[i+1].text == closing {
+				out.WriteString(tok.text + closing)
+				i++
+				continue
+			}
+			out.WriteString(tok.text)
+			depth++
+			pendingNewline = true
+		case tok.text == "}" || tok.text == "]":
+			depth--
+			newLine()
+			out.WriteString(tok.text)
+			pendingNewline = false
+		case tok.text == ",":
+			// 尾随逗号直接丢弃
+			if j := nextSignificant(i); j < len(tokens) && (tokens[j].text == "}" || tokens[j].text == "]") {
+				continue
+			}
+			out.WriteString(",")
+			pendingNewline = true
+		case tok.text == ":":
+			out.WriteString(": ")
+		default:
+			if pendingNewline {
+				newLine()
+			} else {
+				separate()
+			}
+			pendingNewline = false
+			out.WriteString(tok.text)
+		}
+	}
+	out.WriteByte('\n')
+	return out.Bytes()
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// commitHashPattern 用于校验客户端传入的提交哈希
+var commitHashPattern = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+
+// historyGitignore 版本库忽略编辑器自身的数据目录和原子写入产生的临时文件
+const historyGitignore = ".sb_editor/\n.*.tmp-*\n"
+
+// GitCommitInfo 描述版本历史中的一次提交
+type GitCommitInfo struct {
+	Hash    string    `json:"hash"`
+	Author  string    `json:"author"`
+	Time    time.Time `json:"time"`
+	Subject string    `json:"subject"`
+}
+
+// historyEnabled 判断是否启用了 Git 版本历史
+func historyEnabled() bool {
+	return getEditorConfig().Git.Enabled
+}
+
+// runGit 在指定目录执行 git 命令。提交者身份固定为 sb_editor，作者由调用方通过 --author 指定。
+func runGit(dir string, args ...string) (string, error) {
+	fullArgs := append([]string{"-C", dir, "-c", "user.name=sb_editor", "-c", "user.email=sb_editor@localhost", "-c", "core.quotepath=false"}, args...)
+	cmd := exec.Command("git", fullArgs...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	// 只返回标准输出（git show 的结果会被当作文件内容使用），标准错误放进错误信息
+	output, err := cmd.Output()
+	if err != nil {
+		detail := ""
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			detail = strings.TrimSpace(string(exitErr.Stderr))
+		}
+		return string(output), fmt.Errorf("git %s 失败: %v: %s", args[0], err, detail)
+	}
+	return string(output), nil
+}
+
+// ensureHistoryRepo 确保活动配置目录是一个 git 仓库，首次使用时初始化并提交现有文件
+func ensureHistoryRepo() (string, error) {
+	baseDir, err := activeConfigDir()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, ".git")); err == nil {
+		return baseDir, nil
+	}
+	if _, err := runGit(baseDir, "init", "-q"); err != nil {
+		return "", err
+	}
+	gitignore := filepath.Join(baseDir, ".gitignore")
+	if _, err := os.Stat(gitignore); os.IsNotExist(err) {
+		if err := ioutil.WriteFile(gitignore, []byte(historyGitignore), 0644); err != nil {
+			return "", err
+		}
+	}
+	if _, err := runGit(baseDir, "add", "-A"); err != nil {
+		return "", err
+	}
+	if _, err := runGit(baseDir, "commit", "-q", "--allow-empty", "-m", "初始化版本历史"); err != nil {
+		return "", err
+	}
+	log.Printf("已在 %s 初始化 git 版本历史", baseDir)
+	return baseDir, nil
+}
+
+// initHistory 启用版本历史时，在开始编辑之前初始化仓库，使第一次保存也有独立的提交
+func initHistory() {
+	if !historyEnabled() {
+		return
+	}
+	if _, err := ensureHistoryRepo(); err != nil {
+		log.Printf("版本历史不可用: %v", err)
+	}
+}
+
+// recordHistory 把文件的最新内容提交到版本历史。未启用时什么也不做；
+// 失败只记录日志，不影响已经完成的保存。
+func recordHistory(filename, username, detail string) {
+	if !historyEnabled() {
+		return
+	}
+	if err := commitHistory([]string{filename}, username, fmt.Sprintf("%s: %s", filename, detail)); err != nil {
+		log.Printf("版本历史: %v", err)
+	}
+}
+
+// commitHistory 以 username 为作者提交指定文件，没有实际变化时不产生空提交
+func commitHistory(files []string, username, subject string) error {
+	dir, err := ensureHistoryRepo()
+	if err != nil {
+		return err
+	}
+	args := append([]string{"add", "-A", "--"}, files...)
+	if _, err := runGit(dir, args...); err != nil {
+		return err
+	}
+	args = append([]string{"diff", "--cached", "--quiet", "--"}, files...)
+	if _, err := runGit(dir, args...); err == nil {
+		return nil
+	}
+	if username == "" {
+		username = "sb_editor"
+	}
+	message := fmt.Sprintf("%s\n\n用户: %s", subject, username)
+	author := fmt.Sprintf("%s <%s@sb_editor>", username, username)
+	args = append([]string{"commit", "-q", "--author", author, "-m", message, "--"}, files...)
+	_, err = runGit(dir, args...)
+	return err
+}
+
+// revertFileContent 计算撤销提交 commit 对文件 name 的修改后的内容（三方合并），
+// 结果基于磁盘上的当前内容，因此也能保留编辑器之外的修改。
+func revertFileContent(dir, commit, name string) ([]byte, error) {
+	changed, err := runGit(dir, "show", commit+":"+name)
+	if err != nil {
+		return nil, fmt.Errorf("该提交删除了 '%s'，暂不支持撤销", name)
+	}
+	original, err := runGit(dir, "show", commit+"^:"+name)
+	if err != nil {
+		return nil, fmt.Errorf("该提交新增了 '%s'，暂不支持撤销", name)
+	}
+	current, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := ioutil.TempDir("", "sb_editor-revert-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	files := map[string][]byte{"current": current, "base": []byte(changed), "other": []byte(original)}
+	for fname, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, fname), content, 0600); err != nil {
+			return nil, err
+		}
+	}
+	cmd := exec.Command("git", "merge-file", "-p", "--quiet",
+		filepath.Join(tmpDir, "current"), filepath.Join(tmpDir, "base"), filepath.Join(tmpDir, "other"))
+	merged, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("撤销 '%s' 时与之后的修改冲突，请手动处理", name)
+	}
+	return merged, nil
+}
+
+// historyLog 返回版本历史，filename 为空时返回整个目录的历史
+func historyLog(filename string, limit int) ([]GitCommitInfo, error) {
+	dir, err := ensureHistoryRepo()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"log", "-n", strconv.Itoa(limit), "--format=%H%x1f%an%x1f%at%x1f%s"}
+	if filename != "" {
+		args = append(args, "--follow", "--", filename)
+	}
+	output, err := runGit(dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	commits := []GitCommitInfo{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		ts, _ := strconv.ParseInt(fields[2], 10, 64)
+		commits = append(commits, GitCommitInfo{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Time:    time.Unix(ts, 0),
+			Subject: fields[3],
+		})
+	}
+	return commits, nil
+}
+
+// gitLogHandler 处理 /api/git_log?filename=...
+func gitLogHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	if !historyEnabled() {
+		writeJSONError(w, "未启用 git 版本历史，请在编辑器配置中设置 git.enabled。", http.StatusServiceUnavailable)
+		return
+	}
+	filename := r.URL.Query().Get("filename")
+	if filename != "" {
+		if _, err := validateFilename(filename); err != nil {
+			writeJSONError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	commits, err := historyLog(filename, limit)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"filename": filename,
+		"commits":  commits,
+	})
+}
+
+// gitShowHandler 处理 /api/git_show?commit=...，返回提交说明和差异
+func gitShowHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	if !historyEnabled() {
+		writeJSONError(w, "未启用 git 版本历史，请在编辑器配置中设置 git.enabled。", http.StatusServiceUnavailable)
+		return
+	}
+	commit := r.URL.Query().Get("commit")
+	if !commitHashPattern.MatchString(commit) {
+		writeJSONError(w, "非法的提交哈希", http.StatusBadRequest)
+		return
+	}
+	dir, err := ensureHistoryRepo()
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	output, err := runGit(dir, "show", "--format=commit %H%nAuthor: %an%nDate:   %ad%n%n%B", "--date=iso", commit)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(output))
+}
+
+// GitRevertRequest ...
+type GitRevertRequest struct {
+	Commit string `json:"commit"`
+}
+
+// gitRevertHandler 处理 /api/git_revert 请求，用一个新提交撤销指定提交的修改
+func gitRevertHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	if !historyEnabled() {
+		writeJSONError(w, "未启用 git 版本历史，请在编辑器配置中设置 git.enabled。", http.StatusServiceUnavailable)
+		return
+	}
+	var req GitRevertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "无效的请求体", http.StatusBadRequest)
+		return
+	}
+	if !commitHashPattern.MatchString(req.Commit) {
+		writeJSONError(w, "非法的提交哈希", http.StatusBadRequest)
+		return
+	}
+
+	configWriteMutex.Lock()
+	defer configWriteMutex.Unlock()
+
+	dir, err := ensureHistoryRepo()
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// -z 以 NUL 分隔文件名，文件名中的空格和特殊字符都原样保留
+	files, err := runGit(dir, "diff-tree", "-z", "--no-commit-id", "--name-only", "-r", req.Commit)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	var names []string
+	for _, name := range strings.Split(files, "\x00") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		writeJSONError(w, "该提交没有可撤销的文件修改。", http.StatusBadRequest)
+		return
+	}
+
+	// 先计算全部文件的撤销结果，任何一个失败都不写入
+	originals := make(map[string][]byte)
+	reverted := make(map[string][]byte)
+	for _, name := range names {
+		content, err := revertFileContent(dir, req.Commit, name)
+		if err != nil {
+			writeJSONError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if originals[name], err = ioutil.ReadFile(filepath.Join(dir, name)); err != nil {
+			writeJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		reverted[name] = content
+	}
+
+	// 全部备份成功后再逐个原子写入，保持与普通保存相同的权限和属主处理
+	username := sessionUser(r)
+	for _, name := range names {
+		if _, err := createBackup(name, filepath.Join(dir, name)); err != nil {
+			writeJSONError(w, fmt.Sprintf("创建备份失败，已取消撤销：%v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	for i, name := range names {
+		if err := writeConfigFile(filepath.Join(dir, name), reverted[name]); err != nil {
+			// 恢复已经写入的文件，保证所有文件要么全部撤销要么全部不变
+			for _, written := range names[:i] {
+				if restoreErr := writeConfigFile(filepath.Join(dir, written), originals[written]); restoreErr != nil {
+					log.Printf("撤销提交: 无法恢复 '%s': %v", written, restoreErr)
+				}
+			}
+			writeJSONError(w, fmt.Sprintf("写入 '%s' 失败，已撤销全部修改：%v", name, err), http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := commitHistory(names, username, fmt.Sprintf("撤销提交 %s", req.Commit)); err != nil {
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("用户 '%s' 撤销了提交 %s", username, req.Commit)
+	writeJSONResponse(w, "success", fmt.Sprintf("已撤销提交 %s。", req.Commit), http.StatusOK)
+}
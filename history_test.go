@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitRevertHandler(t *testing.T) {
+	editorConfigMutex.Lock()
+	previous := editorConfig.Git.Enabled
+	editorConfig.Git.Enabled = true
+	editorConfigMutex.Unlock()
+	t.Cleanup(func() {
+		editorConfigMutex.Lock()
+		editorConfig.Git.Enabled = previous
+		editorConfigMutex.Unlock()
+	})
+
+	original := map[string]string{
+		"10 my outbounds.json": `{"outbounds": []}`,
+		"20_route.json":        `{"route": {}}`,
+	}
+	dir := useTestConfigDir(t, original)
+	if _, err := ensureHistoryRepo(); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range original {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(`{"log": {}}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	if err := commitHistory(names, "tester", "修改两个文件"); err != nil {
+		t.Fatal(err)
+	}
+	head, err := runGit(dir, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 文件名中含有空格时也能撤销
+	rec := httptest.NewRecorder()
+	gitRevertHandler(rec, httptest.NewRequest(http.MethodPost, "/api/git_revert", strings.NewReader(`{"commit": "`+strings.TrimSpace(head)+`"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+	for name, want := range original {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,570 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/tidwall/gjson"
+)
+
+// 从服务端入站生成客户端配置：先把入站的每个用户转换为对应的出站结构体（与 sharelink.go 的解析结果相同），
+// 再把出站编码为分享链接。
+
+// findInbound 按合并顺序查找标签为 tag 的入站，返回所在文件和入站内容
+func findInbound(tag string) (string, gjson.Result, error) {
+	baseDir, err := activeConfigDir()
+	if err != nil {
+		return "", gjson.Result{}, err
+	}
+	files, err := listConfigFiles()
+	if err != nil {
+		return "", gjson.Result{}, err
+	}
+	for _, file := range files {
+		content, _, err := readFileWithETag(filepath.Join(baseDir, file))
+		if err != nil {
+			return "", gjson.Result{}, fmt.Errorf("无法读取 '%s': %v", file, err)
+		}
+		if err := validateConfigFile(content); err != nil {
+			continue
+		}
+		for _, inbound := range jsoncParse(content).Get("inbounds").Array() {
+			if inbound.Get("tag").String() == tag {
+				return file, inbound, nil
+			}
+		}
+	}
+	return "", gjson.Result{}, fmt.Errorf("入站 '%s' 不存在", tag)
+}
+
+// realityPublicKey 由 Reality 私钥（base64url，sing-box generate reality-keypair 的输出）计算公钥
+func realityPublicKey(privateKey string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(privateKey, "="))
+	if err != nil {
+		return "", fmt.Errorf("Reality 私钥不是有效的 base64: %v", err)
+	}
+	key, err := ecdh.X25519().NewPrivateKey(raw)
+	if err != nil {
+		return "", fmt.Errorf("Reality 私钥无效: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()), nil
+}
+
+// clientTLS 由入站 TLS 推导客户端 TLS：沿用服务器名称和 ALPN；Reality 时由私钥计算公钥，使用第一个 short_id 和 chrome 指纹
+func clientTLS(tls *InboundTLS, insecure bool) (*OutboundTLS, error) {
+	if tls == nil || !tls.Enabled {
+		return nil, nil
+	}
+	out := &OutboundTLS{Enabled: true, ServerName: tls.ServerName, ALPN: tls.ALPN, Insecure: insecure}
+	if reality := tls.Reality; reality != nil && reality.Enabled {
+		publicKey, err := realityPublicKey(reality.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		out.Reality = &OutboundReality{Enabled: true, PublicKey: publicKey}
+		if len(reality.ShortID) > 0 {
+			out.Reality.ShortID = reality.ShortID[0]
+		}
+		if out.ServerName == "" && reality.Handshake != nil {
+			out.ServerName = reality.Handshake.Server
+		}
+		out.UTLS = &OutboundUTLS{Enabled: true, Fingerprint: "chrome"}
+		out.Insecure = false
+	}
+	return out, nil
+}
+
+// ClientOutbound 入站中一个用户对应的客户端出站
+type ClientOutbound struct {
+	User     string
+	Outbound interface{}
+}
+
+// clientOutbounds 把入站的每个用户转换为客户端出站。server 是客户端连接的地址，insecure 表示服务器使用自签名证书。
+func clientOutbounds(inbound gjson.Result, server string, insecure bool) ([]ClientOutbound, error) {
+	var common struct {
+		InboundBase
+		ListenFields
+		TLS       *InboundTLS     `json:"tls"`
+		Transport json.RawMessage `json:"transport"`
+	}
+	if err := json.Unmarshal([]byte(inbound.Raw), &common); err != nil {
+		return nil, fmt.Errorf("入站格式错误: %v", err)
+	}
+	if common.ListenPort == 0 {
+		return nil, fmt.Errorf("入站 '%s' 没有设置 listen_port", common.Tag)
+	}
+	tls, err := clientTLS(common.TLS, insecure)
+	if err != nil {
+		return nil, err
+	}
+	serverFields := ServerFields{Server: server, ServerPort: common.ListenPort}
+	base := func(name string) OutboundBase {
+		tag := common.Tag
+		if name != "" {
+			tag += "-" + name
+		}
+		return OutboundBase{Type: common.Type, Tag: tag}
+	}
+
+	var result []ClientOutbound
+	switch common.Type {
+	case "vless":
+		var in VLESSInbound
+		if err := json.Unmarshal([]byte(inbound.Raw), &in); err != nil {
+			return nil, fmt.Errorf("入站格式错误: %v", err)
+		}
+		for _, user := range in.Users {
+			result = append(result, ClientOutbound{user.Name, &VLESSOutbound{
+				OutboundBase: base(user.Name),
+				ServerFields: serverFields,
+				UUID:         user.UUID,
+				Flow:         user.Flow,
+				TLS:          tls,
+				Transport:    common.Transport,
+			}})
+		}
+	case "vmess":
+		var in VMessInbound
+		if err := json.Unmarshal([]byte(inbound.Raw), &in); err != nil {
+			return nil, fmt.Errorf("入站格式错误: %v", err)
+		}
+		for _, user := range in.Users {
+			result = append(result, ClientOutbound{user.Name, &VMessOutbound{
+				OutboundBase: base(user.Name),
+				ServerFields: serverFields,
+				UUID:         user.UUID,
+				Security:     "auto",
+				AlterID:      user.AlterID,
+				TLS:          tls,
+				Transport:    common.Transport,
+			}})
+		}
+	case "trojan":
+		var in TrojanInbound
+		if err := json.Unmarshal([]byte(inbound.Raw), &in); err != nil {
+			return nil, fmt.Errorf("入站格式错误: %v", err)
+		}
+		for _, user := range in.Users {
+			result = append(result, ClientOutbound{user.Name, &TrojanOutbound{
+				OutboundBase: base(user.Name),
+				ServerFields: serverFields,
+				Password:     user.Password,
+				TLS:          tls,
+				Transport:    common.Transport,
+			}})
+		}
+	case "shadowsocks":
+		var in ShadowsocksInbound
+		if err := json.Unmarshal([]byte(inbound.Raw), &in); err != nil {
+			return nil, fmt.Errorf("入站格式错误: %v", err)
+		}
+		if len(in.Users) == 0 {
+			result = append(result, ClientOutbound{"", &ShadowsocksOutbound{
+				OutboundBase: base(""),
+				ServerFields: serverFields,
+				Method:       in.Method,
+				Password:     in.Password,
+			}})
+		}
+		for _, user := range in.Users {
+			password := user.Password
+			if strings.HasPrefix(in.Method, "2022-") && in.Password != "" {
+				// Shadowsocks 2022 多用户：客户端密码为 服务器密钥:用户密钥
+				password = in.Password + ":" + user.Password
+			}
+			result = append(result, ClientOutbound{user.Name, &ShadowsocksOutbound{
+				OutboundBase: base(user.Name),
+				ServerFields: serverFields,
+				Method:       in.Method,
+				Password:     password,
+			}})
+		}
+	case "hysteria2":
+		var in Hysteria2Inbound
+		if err := json.Unmarshal([]byte(inbound.Raw), &in); err != nil {
+			return nil, fmt.Errorf("入站格式错误: %v", err)
+		}
+		for _, user := range in.Users {
+			result = append(result, ClientOutbound{user.Name, &Hysteria2Outbound{
+				OutboundBase: base(user.Name),
+				ServerFields: serverFields,
+				Obfs:         in.Obfs,
+				Password:     user.Password,
+				TLS:          tls,
+			}})
+		}
+	case "tuic":
+		var in TUICInbound
+		if err := json.Unmarshal([]byte(inbound.Raw), &in); err != nil {
+			return nil, fmt.Errorf("入站格式错误: %v", err)
+		}
+		for _, user := range in.Users {
+			result = append(result, ClientOutbound{user.Name, &TUICOutbound{
+				OutboundBase:      base(user.Name),
+				ServerFields:      serverFields,
+				UUID:              user.UUID,
+				Password:          user.Password,
+				CongestionControl: in.CongestionControl,
+				TLS:               tls,
+			}})
+		}
+	default:
+		return nil, fmt.Errorf("不支持为 %s 入站生成客户端配置", common.Type)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("入站 '%s' 没有用户", common.Tag)
+	}
+	return result, nil
+}
+
+// linkTLSParams 把出站 TLS 写入链接参数，是 shareTLS 的逆过程
+func linkTLSParams(q url.Values, tls *OutboundTLS) {
+	if tls == nil || !tls.Enabled {
+		return
+	}
+	q.Set("security", "tls")
+	if tls.ServerName != "" {
+		q.Set("sni", tls.ServerName)
+	}
+	if len(tls.ALPN) > 0 {
+		q.Set("alpn", strings.Join(tls.ALPN, ","))
+	}
+	if tls.UTLS != nil && tls.UTLS.Enabled && tls.UTLS.Fingerprint != "" {
+		q.Set("fp", tls.UTLS.Fingerprint)
+	}
+	if tls.Insecure {
+		q.Set("allowInsecure", "1")
+	}
+	if tls.Reality != nil && tls.Reality.Enabled {
+		q.Set("security", "reality")
+		q.Set("pbk", tls.Reality.PublicKey)
+		if tls.Reality.ShortID != "" {
+			q.Set("sid", tls.Reality.ShortID)
+		}
+	}
+}
+
+// linkTransportParams 把 V2Ray 传输层写入链接参数（type、host、path、serviceName），是 shareTransport 的逆过程
+func linkTransportParams(q url.Values, transport json.RawMessage) {
+	t := gjson.ParseBytes(transport)
+	network := t.Get("type").String()
+	if network == "" {
+		q.Set("type", "tcp")
+		return
+	}
+	q.Set("type", network)
+	switch network {
+	case "ws":
+		path := t.Get("path").String()
+		if n := t.Get("max_early_data").Int(); n > 0 && t.Get("early_data_header_name").String() == "Sec-WebSocket-Protocol" {
+			path += "?ed=" + strconv.FormatInt(n, 10)
+		}
+		q.Set("path", path)
+		if host := t.Get("headers.Host"); host.Exists() {
+			q.Set("host", firstString(host))
+		}
+	case "grpc":
+		q.Set("serviceName", t.Get("service_name").String())
+	case "http":
+		var hosts []string
+		for _, host := range t.Get("host").Array() {
+			hosts = append(hosts, host.String())
+		}
+		if t.Get("host").Type == gjson.String {
+			hosts = []string{t.Get("host").String()}
+		}
+		q.Set("host", strings.Join(hosts, ","))
+		q.Set("path", t.Get("path").String())
+	case "httpupgrade":
+		q.Set("host", t.Get("host").String())
+		q.Set("path", t.Get("path").String())
+	}
+}
+
+// firstString 取字符串或字符串数组的第一个值
+func firstString(value gjson.Result) string {
+	if value.IsArray() {
+		return value.Get("0").String()
+	}
+	return value.String()
+}
+
+// buildShareURL 组装 scheme://userinfo@host:port?query#tag 形式的链接
+func buildShareURL(scheme string, user *url.Userinfo, server ServerFields, q url.Values, tag string) string {
+	u := url.URL{
+		Scheme:   scheme,
+		User:     user,
+		Host:     net.JoinHostPort(server.Server, strconv.Itoa(int(server.ServerPort))),
+		RawQuery: q.Encode(),
+		Fragment: tag,
+	}
+	return u.String()
+}
+
+// shareLinkFor 把客户端出站编码为分享链接，格式与 parseShareLink 能解析的一致
+func shareLinkFor(outbound interface{}) (string, error) {
+	switch out := outbound.(type) {
+	case *VLESSOutbound:
+		q := url.Values{"encryption": {"none"}}
+		if out.Flow != "" {
+			q.Set("flow", out.Flow)
+		}
+		linkTLSParams(q, out.TLS)
+		linkTransportParams(q, out.Transport)
+		return buildShareURL("vless", url.User(out.UUID), out.ServerFields, q, out.Tag), nil
+	case *TrojanOutbound:
+		q := url.Values{}
+		linkTLSParams(q, out.TLS)
+		linkTransportParams(q, out.Transport)
+		return buildShareURL("trojan", url.User(out.Password), out.ServerFields, q, out.Tag), nil
+	case *VMessOutbound:
+		// v2rayN 格式：base64 编码的 JSON
+		q := url.Values{}
+		linkTLSParams(q, out.TLS)
+		linkTransportParams(q, out.Transport)
+		path := q.Get("path")
+		if q.Get("type") == "grpc" {
+			path = q.Get("serviceName")
+		}
+		if q.Get("security") != "tls" {
+			q.Del("security")
+		}
+		config, err := json.Marshal(map[string]string{
+			"v":    "2",
+			"ps":   out.Tag,
+			"add":  out.Server,
+			"port": strconv.Itoa(int(out.ServerPort)),
+			"id":   out.UUID,
+			"aid":  strconv.Itoa(out.AlterID),
+			"scy":  out.Security,
+			"net":  q.Get("type"),
+			"type": "none",
+			"host": q.Get("host"),
+			"path": path,
+			"tls":  q.Get("security"),
+			"sni":  q.Get("sni"),
+			"alpn": q.Get("alpn"),
+			"fp":   q.Get("fp"),
+		})
+		if err != nil {
+			return "", err
+		}
+		return "vmess://" + base64.StdEncoding.EncodeToString(config), nil
+	case *ShadowsocksOutbound:
+		// SIP002：2022 系列加密方式的用户信息使用百分号编码，其余使用 base64url
+		userInfo := base64.RawURLEncoding.EncodeToString([]byte(out.Method + ":" + out.Password))
+		if strings.HasPrefix(out.Method, "2022-") {
+			userInfo = url.PathEscape(out.Method) + ":" + url.PathEscape(out.Password)
+		}
+		host := net.JoinHostPort(out.Server, strconv.Itoa(int(out.ServerPort)))
+		return "ss://" + userInfo + "@" + host + "#" + (&url.URL{Fragment: out.Tag}).EscapedFragment(), nil
+	case *Hysteria2Outbound:
+		q := url.Values{}
+		if out.TLS != nil {
+			if out.TLS.ServerName != "" {
+				q.Set("sni", out.TLS.ServerName)
+			}
+			if out.TLS.Insecure {
+				q.Set("insecure", "1")
+			}
+		}
+		if out.Obfs != nil && out.Obfs.Type != "" {
+			q.Set("obfs", out.Obfs.Type)
+			q.Set("obfs-password", out.Obfs.Password)
+		}
+		return buildShareURL("hysteria2", url.User(out.Password), out.ServerFields, q, out.Tag), nil
+	case *TUICOutbound:
+		q := url.Values{}
+		if out.CongestionControl != "" {
+			q.Set("congestion_control", out.CongestionControl)
+		}
+		if out.UDPRelayMode != "" {
+			q.Set("udp_relay_mode", out.UDPRelayMode)
+		}
+		if out.TLS != nil {
+			if out.TLS.ServerName != "" {
+				q.Set("sni", out.TLS.ServerName)
+			}
+			if len(out.TLS.ALPN) > 0 {
+				q.Set("alpn", strings.Join(out.TLS.ALPN, ","))
+			}
+			if out.TLS.Insecure {
+				q.Set("allow_insecure", "1")
+			}
+		}
+		return buildShareURL("tuic", url.UserPassword(out.UUID, out.Password), out.ServerFields, q, out.Tag), nil
+	}
+	return "", fmt.Errorf("不支持为 %T 生成分享链接", outbound)
+}
+
+// shareServerAddress 返回客户端连接的服务器地址：请求参数优先，其次是编辑器配置中的 share.server
+func shareServerAddress(r *http.Request) (string, error) {
+	server := strings.TrimSpace(r.URL.Query().Get("server"))
+	if server == "" {
+		server = getEditorConfig().Share.Server
+	}
+	if server == "" {
+		return "", fmt.Errorf("请指定服务器地址（server 参数，或在编辑器配置中设置 share.server）")
+	}
+	return strings.Trim(server, "[]"), nil
+}
+
+// ShareLinkItem ...
+type ShareLinkItem struct {
+	User     string          `json:"user"`
+	Link     string          `json:"link"`
+	Outbound json.RawMessage `json:"outbound"` // 对应的客户端出站配置
+}
+
+// ShareLinksResponse ...
+type ShareLinksResponse struct {
+	Tag    string          `json:"tag"`
+	Type   string          `json:"type"`
+	File   string          `json:"file"`
+	Server string          `json:"server"`
+	Links  []ShareLinkItem `json:"links"`
+}
+
+// shareLinksHandler 处理 /api/share_links 请求：为活动目录中标签为 tag 的入站的每个用户生成客户端分享链接。
+// insecure=1 表示服务器使用自签名证书，客户端需要跳过证书验证。
+func shareLinksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	tag := r.URL.Query().Get("tag")
+	if tag == "" {
+		writeJSONError(w, "缺少入站标签", http.StatusBadRequest)
+		return
+	}
+	server, err := shareServerAddress(r)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, inbound, err := findInbound(tag)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	outbounds, err := clientOutbounds(inbound, server, truthy(r.URL.Query().Get("insecure")))
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	response := ShareLinksResponse{Tag: tag, Type: inbound.Get("type").String(), File: file, Server: server, Links: []ShareLinkItem{}}
+	for _, client := range outbounds {
+		link, err := shareLinkFor(client.Outbound)
+		if err != nil {
+			writeJSONError(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		raw, err := json.Marshal(client.Outbound)
+		if err != nil {
+			writeJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response.Links = append(response.Links, ShareLinkItem{User: client.User, Link: link, Outbound: raw})
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(response)
+}
+
+// qrcodeSVG 把二维码渲染为 SVG，每个模块一个单位，由 size 控制显示尺寸
+func qrcodeSVG(code *qrcode.QRCode, size int) []byte {
+	bitmap := code.Bitmap()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, len(bitmap), len(bitmap))
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, len(bitmap), len(bitmap))
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes()
+}
+
+// qrcodeHandler 处理 /api/qrcode 请求：为入站 tag 的某个用户生成分享链接的二维码图片。
+// 参数 user、server、insecure 同 client_config；链接在服务端生成，其中的 UUID、密码不会出现在 URL 和访问日志中。
+// format 为 png（默认）或 svg，size 为边长像素。
+func qrcodeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	tag := query.Get("tag")
+	if tag == "" {
+		writeJSONError(w, "缺少入站标签", http.StatusBadRequest)
+		return
+	}
+	server, err := shareServerAddress(r)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, inbound, err := findInbound(tag)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	clients, err := clientOutbounds(inbound, server, truthy(query.Get("insecure")))
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	client, err := selectClientUser(clients, query.Get("user"))
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	text, err := shareLinkFor(client.Outbound)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	size := 256
+	if value := query.Get("size"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 64 || n > 2048 {
+			writeJSONError(w, "size 必须在 64 到 2048 之间", http.StatusBadRequest)
+			return
+		}
+		size = n
+	}
+	code, err := qrcode.New(text, qrcode.Medium)
+	if err != nil {
+		writeJSONError(w, fmt.Sprintf("无法生成二维码: %v", err), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	switch query.Get("format") {
+	case "", "png":
+		png, err := code.PNG(size)
+		if err != nil {
+			writeJSONError(w, fmt.Sprintf("无法生成二维码: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	case "svg":
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Write(qrcodeSVG(code, size))
+	default:
+		writeJSONError(w, "format 只能是 png 或 svg", http.StatusBadRequest)
+	}
+}
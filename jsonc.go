@@ -0,0 +1,434 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// JSONC 支持：配置文件允许包含 // 行注释、/* 块注释 */ 和尾随逗号。
+//
+// 核心思路是 stripJSONC 生成一个与原文等长的“影子副本”：注释和尾随逗号被替换为空格，
+// 块注释中的换行保留。gjson 在影子副本上解析得到的偏移量（Result.Index）与原文完全对应，
+// 因此可以直接在原文上做局部替换，文件其他位置的注释和排版都不受影响。
+
+// stripJSONC 返回去掉注释和尾随逗号的等长副本，偏移量和行号与原文一致
+func stripJSONC(content []byte) []byte {
+	out := make([]byte, len(content))
+	copy(out, content)
+
+	inString := false
+	lastComma := -1 // 最近一个尚未确定是否为尾随逗号的逗号位置
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		if inString {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case c == '"':
+			inString = true
+			lastComma = -1
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			out[i], out[i+1] = ' ', ' '
+			i += 2
+			for ; i < len(out); i++ {
+				if out[i] == '*' && i+1 < len(out) && out[i+1] == '/' {
+					out[i], out[i+1] = ' ', ' '
+					i++
+					break
+				}
+				if out[i] != '\n' && out[i] != '\r' {
+					out[i] = ' '
+				}
+			}
+		case c == ',':
+			lastComma = i
+		case c == '}' || c == ']':
+			if lastComma >= 0 {
+				out[lastComma] = ' '
+			}
+			lastComma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			lastComma = -1
+		}
+	}
+	return out
+}
+
+// jsoncHasComments 判断文本中是否含有注释或尾随逗号
+func jsoncHasComments(content []byte) bool {
+	return !bytes.Equal(content, stripJSONC(content))
+}
+
+// jsoncToJSON 去掉注释和尾随逗号，得到 sing-box 兼容的纯 JSON 文本，尽量保持原有排版：
+// 行尾空白被裁掉，只因包含注释而变成空行的行被整行删除。
+func jsoncToJSON(content []byte) []byte {
+	stripped := stripJSONC(content)
+	origLines := bytes.Split(content, []byte("\n"))
+	lines := bytes.Split(stripped, []byte("\n"))
+	var out [][]byte
+	for i, line := range lines {
+		trimmed := bytes.TrimRight(line, " \t\r")
+		if len(bytes.TrimSpace(trimmed)) == 0 && len(bytes.TrimSpace(origLines[i])) != 0 {
+			continue
+		}
+		out = append(out, trimmed)
+	}
+	return bytes.Join(out, []byte("\n"))
+}
+
+// jsoncParse 解析 JSONC 文本的根节点
+func jsoncParse(content []byte) gjson.Result {
+	stripped := stripJSONC(content)
+	root := gjson.ParseBytes(stripped)
+	root.Index = len(stripped) - len(bytes.TrimLeft(stripped, " \t\r\n"))
+	return root
+}
+
+// jsoncGet 按 gjson 路径在 JSONC 文本上取值，结果的 Index 对应原文偏移
+func jsoncGet(content []byte, path string) gjson.Result {
+	if path == "" {
+		return jsoncParse(content)
+	}
+	return gjson.GetBytes(stripJSONC(content), path)
+}
+
+// jsoncRaw 返回结果在原文中对应的文本（包含其中的注释）。
+// 无法可靠定位时（例如使用了 gjson 修饰符的路径）退回到去掉注释的文本。
+func jsoncRaw(content []byte, value gjson.Result) []byte {
+	end := value.Index + len(value.Raw)
+	if value.Index < 0 || end > len(content) {
+		return []byte(value.Raw)
+	}
+	original := content[value.Index:end]
+	if !bytes.Equal(stripJSONC(original), []byte(value.Raw)) {
+		return []byte(value.Raw)
+	}
+	return original
+}
+
+// splitJSONPath 把路径拆成父路径和最后一个键，支持 gjson 的 \. 转义
+func splitJSONPath(path string) (string, string) {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i] == '.' && (i == 0 || path[i-1] != '\\') {
+			return path[:i], strings.ReplaceAll(path[i+1:], `\.`, ".")
+		}
+	}
+	return "", strings.ReplaceAll(path, `\.`, ".")
+}
+
+// lineIndentAt 返回 pos 所在行的前导空白
+func lineIndentAt(content []byte, pos int) string {
+	start := bytes.LastIndexByte(content[:pos], '\n') + 1
+	end := start
+	for end < len(content) && (content[end] == ' ' || content[end] == '\t') {
+		end++
+	}
+	return string(content[start:end])
+}
+
+// jsoncSetRaw 把 path 处的值替换为 raw（原样插入，可以包含注释）。
+// path 不存在时追加到父对象/数组末尾，缺少的上级对象（如在没有 route 的文件中设置 route.final）
+// 与 sjson 一样自动创建。文件其余部分的注释和排版保持不变。
+func jsoncSetRaw(content []byte, path string, raw []byte) ([]byte, error) {
+	if path == "" {
+		return nil, fmt.Errorf("路径不能为空")
+	}
+	stripped := stripJSONC(content)
+	value := gjson.GetBytes(stripped, path)
+	if value.Exists() {
+		if value.Index <= 0 {
+			return nil, fmt.Errorf("无法定位路径 '%s'", path)
+		}
+		return spliceBytes(content, value.Index, value.Index+len(value.Raw), raw), nil
+	}
+
+	parentPath, key := splitJSONPath(path)
+	parent := jsoncGet(content, parentPath)
+	if !parent.Exists() {
+		if _, err := strconv.Atoi(key); err == nil {
+			return nil, fmt.Errorf("父路径 '%s' 不存在", parentPath)
+		}
+		quoted, _ := json.Marshal(key)
+		wrapped := "{" + string(quoted) + ": " + string(raw) + "}"
+		if parentPath == "" {
+			// 空文件：整个文件就是新建的对象
+			if !isBlank(stripped) {
+				return nil, fmt.Errorf("文件内容不是 JSON 对象")
+			}
+			return []byte(wrapped), nil
+		}
+		return jsoncSetRaw(content, parentPath, []byte(wrapped))
+	}
+	if parent.Type != gjson.JSON {
+		return nil, fmt.Errorf("父路径 '%s' 不是对象或数组", parentPath)
+	}
+
+	var entry string
+	if parent.IsArray() {
+		count := len(parent.Array())
+		if index, err := strconv.Atoi(key); err != nil || (index != -1 && index != count) {
+			return nil, fmt.Errorf("数组 '%s' 只能在末尾追加元素", parentPath)
+		}
+		entry = string(raw)
+	} else {
+		quoted, _ := json.Marshal(key)
+		entry = string(quoted) + ": " + string(raw)
+	}
+
+	// 找到最后一个子元素的结束位置；没有子元素时插在左括号之后
+	lastEnd := -1
+	lastStart := -1
+	parent.ForEach(func(k, v gjson.Result) bool {
+		lastEnd = v.Index + len(v.Raw)
+		lastStart = v.Index
+		if parent.IsObject() {
+			lastStart = k.Index
+		}
+		return true
+	})
+	if lastEnd < 0 {
+		open := parent.Index + 1
+		closeIdx := parent.Index + len(parent.Raw) - 1
+		indent := lineIndentAt(content, parent.Index)
+		if len(bytes.TrimSpace(content[open:closeIdx])) != 0 {
+			// 空容器里只有注释：插在左括号之后，保留注释
+			return spliceBytes(content, open, open, []byte("\n"+indent+"  "+entry)), nil
+		}
+		if bytes.ContainsRune(content[open:closeIdx], '\n') || bytes.ContainsRune(raw, '\n') {
+			insert := "\n" + indent + "  " + entry + "\n" + indent
+			return spliceBytes(content, open, closeIdx, []byte(insert)), nil
+		}
+		return spliceBytes(content, open, closeIdx, []byte(entry)), nil
+	}
+
+	separator := ", "
+	if bytes.ContainsRune(stripped[parent.Index:lastStart], '\n') {
+		separator = ",\n" + lineIndentAt(content, lastStart)
+	}
+	return spliceBytes(content, lastEnd, lastEnd, []byte(separator+entry)), nil
+}
+
+// spliceBytes 用 insert 替换 content[start:end]
+func spliceBytes(content []byte, start, end int, insert []byte) []byte {
+	out := make([]byte, 0, len(content)-(end-start)+len(insert))
+	out = append(out, content[:start]...)
+	out = append(out, insert...)
+	out = append(out, content[end:]...)
+	return out
+}
+
+// jsoncArrayElements 返回 path 处的数组及其所有元素
+func jsoncArrayElements(content []byte, path string) (gjson.Result, []gjson.Result, error) {
+	list := jsoncGet(content, path)
+	if !list.IsArray() {
+		return list, nil, fmt.Errorf("'%s' 不是数组", path)
+	}
+	return list, list.Array(), nil
+}
+
+// arrayElementSpan 数组元素在原文中占据的范围
+type arrayElementSpan struct {
+	start, end int    // 删除元素时要移除的原文范围，包含其后的逗号、同一行的行尾注释和前面独占整行的注释
+	prevComma  int    // 删除最后一个元素时还要移除的前一个逗号的位置，-1 表示没有
+	ownLines   bool   // 元素独占若干整行（多行排版）
+	comma      bool   // 元素后面有逗号，对最后一个元素来说即尾随逗号
+	leading    string // 元素前面独占整行的注释（带缩进和换行），移动元素时随之移动
+	trailing   string // 元素及其逗号之后、同一行的行尾注释（带前导空白），移动元素时随之移动
+}
+
+// isBlank 判断文本是否只包含空白
+func isBlank(b []byte) bool {
+	return len(bytes.TrimSpace(b)) == 0
+}
+
+// jsoncElementSpan 计算数组第 i 个元素的范围。stripped 是 content 的 stripJSONC 结果。
+func jsoncElementSpan(content, stripped []byte, elems []gjson.Result, i int) arrayElementSpan {
+	elem := elems[i]
+	span := arrayElementSpan{start: elem.Index, end: elem.Index + len(elem.Raw), prevComma: -1}
+
+	lineStart := bytes.LastIndexByte(content[:span.start], '\n') + 1
+	if isBlank(content[lineStart:span.start]) {
+		span.ownLines = true
+		span.start = lineStart
+		// 向上包含紧挨着的注释行（原文非空、去掉注释后为空的行）
+		for span.start > 0 {
+			prevStart := bytes.LastIndexByte(content[:span.start-1], '\n') + 1
+			if isBlank(content[prevStart:span.start-1]) || !isBlank(stripped[prevStart:span.start-1]) {
+				break
+			}
+			span.start = prevStart
+		}
+		span.leading = string(content[span.start:lineStart])
+	}
+
+	last := i == len(elems)-1
+	j := span.end
+	if last {
+		// 尾随逗号在 stripped 中已被替换为空格，只能在原文中找
+		for j < len(content) && (content[j] == ' ' || content[j] == '\t') {
+			j++
+		}
+		span.comma = j < len(content) && content[j] == ','
+	} else {
+		for j < len(stripped) && isBlank(stripped[j:j+1]) {
+			j++
+		}
+		span.comma = j < len(stripped) && stripped[j] == ','
+	}
+	if span.comma {
+		span.end = j + 1
+	}
+	if span.ownLines {
+		// 行尾只剩空白或注释时整行删除，行尾注释记入 trailing；跨行的块注释不拆开
+		lineEnd := bytes.IndexByte(stripped[span.end:], '\n')
+		if lineEnd >= 0 && isBlank(stripped[span.end:span.end+lineEnd]) {
+			rest := string(bytes.TrimRight(content[span.end:span.end+lineEnd], " \t\r"))
+			if open := strings.LastIndex(rest, "/*"); open < 0 || strings.Contains(rest[open:], "*/") {
+				if !isBlank([]byte(rest)) {
+					span.trailing = rest
+				}
+				span.end += lineEnd + 1
+			}
+		}
+	} else if !last {
+		for span.end < len(content) && (content[span.end] == ' ' || content[span.end] == '\t') {
+			span.end++
+		}
+	}
+
+	// 多行排版且有尾随逗号时，前一个元素的逗号成为新的尾随逗号，保留不动
+	if last && i > 0 && !(span.comma && span.ownLines) {
+		prev := elems[i-1]
+		j := prev.Index + len(prev.Raw)
+		for j < span.start && isBlank(stripped[j:j+1]) {
+			j++
+		}
+		if j < span.start && stripped[j] == ',' {
+			if span.ownLines {
+				span.prevComma = j
+			} else {
+				// 单行排版：连同前一个逗号和空格一起删除，如 [1, 2] -> [1]
+				span.start = j
+			}
+		}
+	}
+	return span
+}
+
+// jsoncDeleteElement 删除数组 path 的第 index 个元素，元素前独占整行的注释一并删除
+func jsoncDeleteElement(content []byte, path string, index int) ([]byte, error) {
+	_, elems, err := jsoncArrayElements(content, path)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(elems) {
+		return nil, fmt.Errorf("'%s' 中没有第 %d 个元素", path, index)
+	}
+	span := jsoncElementSpan(content, stripJSONC(content), elems, index)
+	content = spliceBytes(content, span.start, span.end, nil)
+	if span.prevComma >= 0 {
+		content = spliceBytes(content, span.prevComma, span.prevComma+1, nil)
+	}
+	return content, nil
+}
+
+// jsoncInsertElement 在数组 path 的第 index 个位置插入 raw（index 等于元素个数时追加到末尾）。
+// leading 是要放在元素前面的整行注释，trailing 是放在元素同一行末尾的注释，只在多行排版时使用；
+// 多行排版时 raw 的后续各行应已带好缩进。
+func jsoncInsertElement(content []byte, path string, index int, raw []byte, leading, trailing string) ([]byte, error) {
+	_, elems, err := jsoncArrayElements(content, path)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index > len(elems) {
+		return nil, fmt.Errorf("插入位置 %d 超出了 '%s' 的范围 (0-%d)", index, path, len(elems))
+	}
+	stripped := stripJSONC(content)
+	if index == len(elems) && index > 0 {
+		prev := elems[index-1]
+		last := jsoncElementSpan(content, stripped, elems, index-1)
+		if last.ownLines && content[last.end-1] == '\n' {
+			// 另起一行放在最后一个元素所在行（含行尾注释）之后，并沿用是否写尾随逗号的习惯
+			comma := ""
+			if last.comma {
+				comma = ","
+			}
+			insert := leading + lineIndentAt(content, prev.Index) + string(raw) + comma + trailing + "\n"
+			content = spliceBytes(content, last.end, last.end, []byte(insert))
+			if !last.comma {
+				prevEnd := prev.Index + len(prev.Raw)
+				content = spliceBytes(content, prevEnd, prevEnd, []byte(","))
+			}
+			return content, nil
+		}
+	}
+	if index == len(elems) {
+		entry := raw
+		if leading != "" {
+			indent := ""
+			if len(elems) > 0 {
+				indent = lineIndentAt(content, elems[len(elems)-1].Index)
+			}
+			entry = []byte(strings.TrimLeft(leading, " \t") + indent + string(raw))
+		}
+		return jsoncSetRaw(content, path+".-1", entry)
+	}
+	target := jsoncElementSpan(content, stripped, elems, index)
+	if target.ownLines {
+		indent := lineIndentAt(content, elems[index].Index)
+		insert := leading + indent + string(raw) + "," + trailing + "\n"
+		return spliceBytes(content, target.start, target.start, []byte(insert)), nil
+	}
+	return spliceBytes(content, elems[index].Index, elems[index].Index, append(append([]byte{}, raw...), ", "...)), nil
+}
+
+// jsoncMoveElement 把数组 path 的第 from 个元素移动到第 to 个位置，元素前的注释和行尾注释随之移动
+func jsoncMoveElement(content []byte, path string, from, to int) ([]byte, error) {
+	_, elems, err := jsoncArrayElements(content, path)
+	if err != nil {
+		return nil, err
+	}
+	if from < 0 || from >= len(elems) || to < 0 || to >= len(elems) {
+		return nil, fmt.Errorf("移动位置超出了 '%s' 的范围 (0-%d)", path, len(elems)-1)
+	}
+	if from == to {
+		return content, nil
+	}
+	elem := elems[from]
+	raw := append([]byte{}, content[elem.Index:elem.Index+len(elem.Raw)]...)
+	span := jsoncElementSpan(content, stripJSONC(content), elems, from)
+	deleted, err := jsoncDeleteElement(content, path, from)
+	if err != nil {
+		return nil, err
+	}
+	return jsoncInsertElement(deleted, path, to, raw, span.leading, span.trailing)
+}
+
+// jsoncArrayElementIndent 返回在数组 path 中新插入元素时应使用的缩进，
+// multiline 为 false 表示数组是单行排版（如 ["a", "b"]），新元素也应写成一行。空数组按多行处理。
+func jsoncArrayElementIndent(content []byte, path string) (indent string, multiline bool) {
+	list, elems, err := jsoncArrayElements(content, path)
+	if err != nil {
+		return "", false
+	}
+	if len(elems) > 0 {
+		span := jsoncElementSpan(content, stripJSONC(content), elems, 0)
+		return lineIndentAt(content, elems[0].Index), span.ownLines
+	}
+	return lineIndentAt(content, list.Index) + "  ", true
+}
@@ -0,0 +1,84 @@
+package main
+
+import "testing"
+
+func TestJSONCMoveElement(t *testing.T) {
+	trailingComma := "{\"a\": [\n  // lx\n  \"x\", // cx\n  \"y\", /* cy */\n  \"z\", // cz\n]}"
+	noTrailingComma := "{\"a\": [\n  \"x\", // cx\n  \"y\", // cy\n  \"z\" // cz\n]}"
+	tests := []struct {
+		content  string
+		from, to int
+		want     string
+	}{
+		// 元素前的整行注释和行尾注释都随元素移动，尾随逗号的习惯保持不变
+		{trailingComma, 2, 0, "{\"a\": [\n  \"z\", // cz\n  // lx\n  \"x\", // cx\n  \"y\", /* cy */\n]}"},
+		{trailingComma, 0, 2, "{\"a\": [\n  \"y\", /* cy */\n  \"z\", // cz\n  // lx\n  \"x\", // cx\n]}"},
+		{trailingComma, 1, 2, "{\"a\": [\n  // lx\n  \"x\", // cx\n  \"z\", // cz\n  \"y\", /* cy */\n]}"},
+		{noTrailingComma, 2, 0, "{\"a\": [\n  \"z\", // cz\n  \"x\", // cx\n  \"y\" // cy\n]}"},
+		{noTrailingComma, 0, 2, "{\"a\": [\n  \"y\", // cy\n  \"z\", // cz\n  \"x\" // cx\n]}"},
+		{noTrailingComma, 0, 1, "{\"a\": [\n  \"y\", // cy\n  \"x\", // cx\n  \"z\" // cz\n]}"},
+		{`{"a": ["x", "y", "z"]}`, 2, 0, `{"a": ["z", "x", "y"]}`},
+		{`{"a": ["x", "y", "z"]}`, 0, 2, `{"a": ["y", "z", "x"]}`},
+	}
+	for _, tt := range tests {
+		got, err := jsoncMoveElement([]byte(tt.content), "a", tt.from, tt.to)
+		if err != nil {
+			t.Errorf("move %d -> %d: %v", tt.from, tt.to, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("move %d -> %d in\n%s\ngot\n%s\nwant\n%s", tt.from, tt.to, tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestJSONCDeleteElement(t *testing.T) {
+	tests := []struct {
+		content string
+		index   int
+		want    string
+	}{
+		{"{\"a\": [\n  \"x\", // cx\n  \"y\" // cy\n]}", 1, "{\"a\": [\n  \"x\" // cx\n]}"},
+		{"{\"a\": [\n  \"x\", // cx\n  \"y\", // cy\n]}", 1, "{\"a\": [\n  \"x\", // cx\n]}"},
+		{"{\"a\": [\n  // lx\n  \"x\", // cx\n  \"y\"\n]}", 0, "{\"a\": [\n  \"y\"\n]}"},
+		{`{"a": ["x", "y",]}`, 1, `{"a": ["x"]}`},
+		{`{"a": ["x", "y"]}`, 0, `{"a": ["y"]}`},
+	}
+	for _, tt := range tests {
+		got, err := jsoncDeleteElement([]byte(tt.content), "a", tt.index)
+		if err != nil {
+			t.Errorf("delete %d: %v", tt.index, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("delete %d in\n%s\ngot\n%s\nwant\n%s", tt.index, tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestJSONCSetRawCreatesParents(t *testing.T) {
+	tests := []struct {
+		content, path, raw string
+		want               string
+	}{
+		{"{\n  // 日志\n  \"log\": {}\n}", "route.final", `"direct"`, "{\n  // 日志\n  \"log\": {},\n  \"route\": {\"final\": \"direct\"}\n}"},
+		{`{"route": {}}`, "route.final", `"direct"`, `{"route": {"final": "direct"}}`},
+		{`{"dns": {}}`, "dns.fakeip.inet4_range", `"198.18.0.0/15"`, `{"dns": {"fakeip": {"inet4_range": "198.18.0.0/15"}}}`},
+		{"", "log.level", `"info"`, `{"log": {"level": "info"}}`},
+	}
+	for _, tt := range tests {
+		got, err := jsoncSetRaw([]byte(tt.content), tt.path, []byte(tt.raw))
+		if err != nil {
+			t.Errorf("set %s: %v", tt.path, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("set %s in\n%s\ngot\n%s\nwant\n%s", tt.path, tt.content, got, tt.want)
+		}
+	}
+
+	// 缺少的上级是数组元素时无法推断，仍然报错
+	if _, err := jsoncSetRaw([]byte(`{"route": {}}`), "route.rules.0.outbound", []byte(`"direct"`)); err == nil {
+		t.Error("set route.rules.0.outbound succeeded, want error")
+	}
+}
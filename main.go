@@ -14,26 +14,88 @@ var staticContent embed.FS
 
 // 解析HTML模板
 var templates = template.Must(template.ParseFS(staticContent, "templates/index.html"))
+var loginTemplate = template.Must(template.ParseFS(staticContent, "templates/login.html"))
 
 func main() {
 	port := flag.Int("p", 80, "Port to listen on")
+	editorConfigFile := flag.String("c", DEFAULT_EDITOR_CONFIG_PATH, "Editor config file (accounts etc.)")
+	setUser := flag.String("user", "", "Create or update this account, then exit (use with -password)")
+	setPassword := flag.String("password", "", "Password for -user")
 	flag.Parse()
 
+	// 0. 加载编辑器自身配置 (来自 editor_config.go)
+	if err := loadEditorConfig(*editorConfigFile); err != nil {
+		log.Fatalf("%v", err)
+	}
+	if *setUser != "" {
+		if err := setUserPassword(*setUser, *setPassword); err != nil {
+			log.Fatalf("设置账户失败: %v", err)
+		}
+		fmt.Printf("账户 '%s' 已保存到 %s\n", *setUser, editorConfigPath)
+		return
+	}
+	if err := ensureDefaultUser(); err != nil {
+		log.Fatalf("无法创建默认账户: %v", err)
+	}
+
 	// 1. 初始化配置路径 (来自 config.go)
 	_, _, _ = initConfigPaths()
+	initHistory()
+	go runSubscriptionScheduler()
 
 	addr := fmt.Sprintf("0.0.0.0:%d", *port)
 
 	// 2. 注册路由 (处理函数都在 api.go 中)
 	http.HandleFunc("/", rootHandler)
+	http.HandleFunc("/login", loginPageHandler)
+	http.HandleFunc("/api/login", loginHandler)
+	http.HandleFunc("/api/logout", logoutHandler)
+	http.HandleFunc("/api/current_user", currentUserHandler)
 	http.HandleFunc("/api/get_config_paths", getConfigPathsHandler)
 	http.HandleFunc("/api/set_active_config_path", setActiveConfigPathHandler)
 	http.HandleFunc("/api/get_functional_configs", getFunctionalConfigsHandler)
 	http.HandleFunc("/api/get_top_keys", getTopKeysHandler)
+	http.HandleFunc("/api/get_tree", getTreeHandler)
 	http.HandleFunc("/api/get_content", getFileContentHandler)
 	http.HandleFunc("/api/save_content", saveFileContentHandler)
 	http.HandleFunc("/api/restart_singbox", restartSingboxHandler)
+	http.HandleFunc("/api/safe_restart", safeRestartHandler)
 	http.HandleFunc("/api/check_config", checkConfigHandler)
+	http.HandleFunc("/api/validate_schema", validateSchemaHandler)
+	http.HandleFunc("/api/field_schema", fieldSchemaHandler)
+	http.HandleFunc("/api/check_tags", checkTagsHandler)
+	http.HandleFunc("/api/rename_tag", renameTagHandler)
+	http.HandleFunc("/api/insert_element", insertElementHandler)
+	http.HandleFunc("/api/delete_element", deleteElementHandler)
+	http.HandleFunc("/api/move_element", moveElementHandler)
+	http.HandleFunc("/api/clone_element", cloneElementHandler)
+	http.HandleFunc("/api/route_rules", routeRulesHandler)
+	http.HandleFunc("/api/test_route", testRouteHandler)
+	http.HandleFunc("/api/import_links", importLinksHandler)
+	http.HandleFunc("/api/share_links", shareLinksHandler)
+	http.HandleFunc("/api/qrcode", qrcodeHandler)
+	http.HandleFunc("/api/client_templates", clientTemplatesHandler)
+	http.HandleFunc("/api/client_config", clientConfigHandler)
+	http.HandleFunc("/api/subscriptions", subscriptionsHandler)
+	http.HandleFunc("/api/save_subscription", saveSubscriptionHandler)
+	http.HandleFunc("/api/delete_subscription", deleteSubscriptionHandler)
+	http.HandleFunc("/api/refresh_subscription", refreshSubscriptionHandler)
+	http.HandleFunc("/api/import_clash", importClashHandler)
+	http.HandleFunc("/api/merged_config", mergedConfigHandler)
+	http.HandleFunc("/api/file_templates", fileTemplatesHandler)
+	http.HandleFunc("/api/create_file", createFileHandler)
+	http.HandleFunc("/api/rename_file", renameFileHandler)
+	http.HandleFunc("/api/duplicate_file", duplicateFileHandler)
+	http.HandleFunc("/api/delete_file", deleteFileHandler)
+	http.HandleFunc("/api/list_trash", listTrashHandler)
+	http.HandleFunc("/api/restore_trash", restoreTrashHandler)
+	http.HandleFunc("/api/list_backups", listBackupsHandler)
+	http.HandleFunc("/api/get_backup", getBackupHandler)
+	http.HandleFunc("/api/diff_backup", diffBackupHandler)
+	http.HandleFunc("/api/restore_backup", restoreBackupHandler)
+	http.HandleFunc("/api/git_log", gitLogHandler)
+	http.HandleFunc("/api/git_show", gitShowHandler)
+	http.HandleFunc("/api/git_revert", gitRevertHandler)
 
 	// 3. 打印启动信息
 	fmt.Printf("Go Web 服务器正在监听地址: %s\n", addr)
@@ -49,9 +111,10 @@ func main() {
 	fmt.Println("\n***** 注意事项 *****")
 	fmt.Println("1. 端口 80 是特权端口，程序可能需要 root 权限运行。")
 	fmt.Println("2. 请确保已配置 sudo 免密重启权限。")
+	fmt.Println("3. 所有页面和 API 都需要登录，账户保存在", editorConfigPath)
 
-	// 4. 启动服务器
-	err := http.ListenAndServe(addr, nil)
+	// 4. 启动服务器 (所有路由都经过登录校验中间件，见 auth.go)
+	err := http.ListenAndServe(addr, authMiddleware(http.DefaultServeMux))
 	if err != nil {
 		log.Fatalf("无法启动服务器: %v", err)
 	}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// 合并视图：sing-box 以 -C 指定目录运行时，会按文件名顺序读取目录中的 .json 文件并依次合并，
+// 规则与 sing-box 的 badjson.MergeJSON 相同：
+//   - 对象与对象合并，键按首次出现的顺序排列；
+//   - 数组与数组拼接，先读到的文件在前；数组遇到非数组值时把它追加为一个元素；
+//   - 其他情况（字符串、数字等标量重复出现）保留先读到的值，后面的文件被忽略；
+//   - 对象遇到非对象值时报错，sing-box 会拒绝启动。
+
+// mergeNode 合并结果中的一个值，记录它来自哪个文件的哪个路径
+type mergeNode struct {
+	object bool
+	array  bool
+	keys   []string // 对象的键，保持顺序
+	fields map[string]*mergeNode
+	items  []*mergeNode
+	raw    string // 标量值的原文
+
+	file   string
+	path   string
+	line   int
+	merged []string // 合并进这个对象或数组的其他文件
+}
+
+// newMergeNode 把一个文件中解析出的值转换为 mergeNode
+func newMergeNode(file string, content []byte, value gjson.Result, path string) *mergeNode {
+	line, _ := offsetPosition(content, value.Index)
+	node := &mergeNode{file: file, path: path, line: line}
+	switch {
+	case value.IsObject():
+		node.object = true
+		node.fields = make(map[string]*mergeNode)
+		value.ForEach(func(key, item gjson.Result) bool {
+			if _, ok := node.fields[key.Str]; !ok {
+				node.keys = append(node.keys, key.Str)
+			}
+			node.fields[key.Str] = newMergeNode(file, content, item, joinJSONPath(path, key.Str))
+			return true
+		})
+	case value.IsArray():
+		node.array = true
+		value.ForEach(func(key, item gjson.Result) bool {
+			node.items = append(node.items, newMergeNode(file, content, item, joinJSONPath(path, strconv.Itoa(int(key.Num)))))
+			return true
+		})
+	default:
+		node.raw = value.Raw
+	}
+	return node
+}
+
+func (n *mergeNode) typeName() string {
+	switch {
+	case n.object:
+		return "对象"
+	case n.array:
+		return "数组"
+	}
+	return "值 " + n.raw
+}
+
+// mergeInto 把 source（后读到的文件）合并到 destination（已合并的结果）中，返回合并后的值
+func mergeInto(source, destination *mergeNode, path string) (*mergeNode, error) {
+	switch {
+	case destination.array:
+		if source.array {
+			destination.items = append(destination.items, source.items...)
+		} else {
+			destination.items = append(destination.items, source)
+		}
+		destination.addMerged(source.file)
+		return destination, nil
+	case destination.object:
+		if !source.object {
+			return nil, fmt.Errorf("%s: 无法把 %s 中的%s合并到对象中", displayPath(path), source.file, source.typeName())
+		}
+		for _, key := range source.keys {
+			value := source.fields[key]
+			if old, ok := destination.fields[key]; ok {
+				merged, err := mergeInto(value, old, joinJSONPath(path, key))
+				if err != nil {
+					return nil, err
+				}
+				destination.fields[key] = merged
+				continue
+			}
+			destination.keys = append(destination.keys, key)
+			destination.fields[key] = value
+		}
+		destination.addMerged(source.file)
+		return destination, nil
+	default:
+		return destination, nil
+	}
+}
+
+// addMerged 记录参与合并的其他文件
+func (n *mergeNode) addMerged(file string) {
+	if file != n.file && !containsString(n.merged, file) {
+		n.merged = append(n.merged, file)
+	}
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "(根)"
+	}
+	return path
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// MergedLine 合并结果中一行内容的来源
+type MergedLine struct {
+	Path       string   `json:"path"`                  // 在合并结果中的路径
+	File       string   `json:"file"`                  // 来源文件
+	SourcePath string   `json:"source_path"`           // 在来源文件中的路径
+	Line       int      `json:"line"`                  // 在来源文件中的行号
+	MergedFrom []string `json:"merged_from,omitempty"` // 对象或数组由多个文件合并而成时，其他参与合并的文件
+}
+
+// mergeRenderer 把合并结果排版为 JSON 文本，同时记录每一行的来源
+type mergeRenderer struct {
+	lines   []string
+	sources []MergedLine
+}
+
+func (r *mergeRenderer) emit(depth int, text string, node *mergeNode, path string) {
+	r.lines = append(r.lines, strings.Repeat("  ", depth)+text)
+	r.sources = append(r.sources, MergedLine{
+		Path:       path,
+		File:       node.file,
+		SourcePath: node.path,
+		Line:       node.line,
+		MergedFrom: node.merged,
+	})
+}
+
+// render 输出一个值，prefix 是对象成员的 "key": 前缀，suffix 是分隔逗号
+func (r *mergeRenderer) render(node *mergeNode, depth int, prefix, suffix, path string) {
+	switch {
+	case node.object:
+		if len(node.keys) == 0 {
+			r.emit(depth, prefix+"{}"+suffix, node, path)
+			return
+		}
+		r.emit(depth, prefix+"{", node, path)
+		for i, key := range node.keys {
+			sep := ","
+			if i == len(node.keys)-1 {
+				sep = ""
+			}
+			r.render(node.fields[key], depth+1, string(jsonQuote(key))+": ", sep, joinJSONPath(path, key))
+		}
+		r.emit(depth, "}"+suffix, node, path)
+	case node.array:
+		if len(node.items) == 0 {
+			r.emit(depth, prefix+"[]"+suffix, node, path)
+			return
+		}
+		r.emit(depth, prefix+"[", node, path)
+		for i, item := range node.items {
+			sep := ","
+			if i == len(node.items)-1 {
+				sep = ""
+			}
+			r.render(item, depth+1, "", sep, joinJSONPath(path, strconv.Itoa(i)))
+		}
+		r.emit(depth, "]"+suffix, node, path)
+	default:
+		r.emit(depth, prefix+node.raw+suffix, node, path)
+	}
+}
+
+// MergedConfigResponse ...
+type MergedConfigResponse struct {
+	Files   []string     `json:"files"`   // 参与合并的文件，按合并顺序
+	Content string       `json:"content"` // 合并后的完整配置
+	Lines   []MergedLine `json:"lines"`   // 与 Content 的每一行一一对应
+}
+
+// buildMergedConfig 按 sing-box 的规则合并活动目录中的所有配置文件
+func buildMergedConfig() (*MergedConfigResponse, error) {
+	baseDir, err := activeConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := listConfigFiles()
+	if err != nil {
+		return nil, err
+	}
+	var merged *mergeNode
+	for _, file := range files {
+		content, err := ioutil.ReadFile(filepath.Join(baseDir, file))
+		if err != nil {
+			return nil, fmt.Errorf("无法读取 '%s': %v", file, err)
+		}
+		if err := validateConfigFile(content); err != nil {
+			return nil, fmt.Errorf("'%s' %v", file, err)
+		}
+		node := newMergeNode(file, content, jsoncParse(content), "")
+		if merged == nil {
+			merged = node
+			continue
+		}
+		if merged, err = mergeInto(node, merged, ""); err != nil {
+			return nil, fmt.Errorf("合并 '%s' 失败，sing-box 将无法启动：%v", file, err)
+		}
+	}
+	if merged == nil {
+		merged = &mergeNode{object: true, fields: map[string]*mergeNode{}}
+	}
+
+	r := &mergeRenderer{}
+	r.render(merged, 0, "", "", "")
+	if files == nil {
+		files = []string{}
+	}
+	return &MergedConfigResponse{
+		Files:   files,
+		Content: strings.Join(r.lines, "\n") + "\n",
+		Lines:   r.sources,
+	}, nil
+}
+
+// mergedConfigHandler 处理 /api/merged_config 请求，返回 sing-box 实际运行的合并配置及每一行的来源
+func mergedConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, err := activeConfigDir(); err != nil {
+		writeJSONError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	resp, err := buildMergedConfig()
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(resp)
+}
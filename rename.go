@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RenameTagRequest ...
+type RenameTagRequest struct {
+	Kind    string `json:"kind"` // outbound / inbound / dns_server / rule_set
+	Old     string `json:"old"`
+	New     string `json:"new"`
+	Preview bool   `json:"preview,omitempty"` // 为 true 时只返回受影响的位置，不写入
+}
+
+// RenameTagResponse ...
+type RenameTagResponse struct {
+	Status   string          `json:"status"`
+	Message  string          `json:"message"`
+	Affected []TagOccurrence `json:"affected"`
+	Files    []string        `json:"files"`
+}
+
+// jsonQuote 把字符串编码为 JSON 字符串字面量（不转义 HTML 字符）
+func jsonQuote(s string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
+
+// renameTagInContent 把 occurrences（同一文件）中的每个字符串替换为新标签，从后往前替换以保持偏移量有效
+func renameTagInContent(content []byte, occurrences []TagOccurrence, newTag string) ([]byte, error) {
+	sorted := append([]TagOccurrence(nil), occurrences...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Offset > sorted[j].Offset })
+	quoted := jsonQuote(newTag)
+	for _, occ := range sorted {
+		end := occ.Offset + len(occ.Raw)
+		if occ.Offset < 0 || end > len(content) || string(content[occ.Offset:end]) != occ.Raw {
+			return nil, fmt.Errorf("文件 '%s' 在 %s 处的内容已变化", occ.File, occ.Path)
+		}
+		content = spliceBytes(content, occ.Offset, end, quoted)
+	}
+	return content, nil
+}
+
+// renameTagHandler 处理 /api/rename_tag 请求：在活动目录的所有文件中重命名一个标签及其全部引用。
+// 所有文件的新内容先全部计算并校验，写入过程中任何一个文件失败都会把已写入的文件恢复原状。
+func renameTagHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	var req RenameTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "无效的请求体", http.StatusBadRequest)
+		return
+	}
+	switch req.Kind {
+	case tagKindOutbound, tagKindInbound, tagKindDNSServer, tagKindRuleSet:
+	default:
+		writeJSONError(w, fmt.Sprintf("不支持的标签种类 '%s'", req.Kind), http.StatusBadRequest)
+		return
+	}
+	req.New = strings.TrimSpace(req.New)
+	if req.Old == "" || req.New == "" {
+		writeJSONError(w, "原标签和新标签都不能为空", http.StatusBadRequest)
+		return
+	}
+	if req.Old == req.New {
+		writeJSONError(w, "新标签与原标签相同", http.StatusBadRequest)
+		return
+	}
+
+	configWriteMutex.Lock()
+	defer configWriteMutex.Unlock()
+
+	index, err := buildTagIndex()
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	// 无法解析的文件中可能也有引用，此时重命名会留下悬空引用
+	if len(index.Errors) > 0 {
+		writeJSONError(w, fmt.Sprintf("以下文件无法解析，请先修复后再重命名：\n%s", strings.Join(index.Errors, "\n")), http.StatusUnprocessableEntity)
+		return
+	}
+	defs := index.definitions(req.Kind)
+	if _, ok := defs[req.Old]; !ok {
+		writeJSONError(w, fmt.Sprintf("标签 '%s' 不存在", req.Old), http.StatusNotFound)
+		return
+	}
+	if _, ok := defs[req.New]; ok {
+		writeJSONError(w, fmt.Sprintf("标签 '%s' 已经存在，拒绝重命名", req.New), http.StatusConflict)
+		return
+	}
+
+	resp := RenameTagResponse{Affected: []TagOccurrence{}, Files: []string{}}
+	byFile := make(map[string][]TagOccurrence)
+	for _, occ := range index.Occurrences {
+		if occ.Kind == req.Kind && occ.Tag == req.Old {
+			resp.Affected = append(resp.Affected, occ)
+			if _, ok := byFile[occ.File]; !ok {
+				resp.Files = append(resp.Files, occ.File)
+			}
+			byFile[occ.File] = append(byFile[occ.File], occ)
+		}
+	}
+
+	if req.Preview {
+		resp.Status = "preview"
+		resp.Message = fmt.Sprintf("将修改 %d 个文件中的 %d 处。", len(resp.Files), len(resp.Affected))
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	baseDir, err := activeConfigDir()
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	// 先计算所有文件的新内容，任何一个失败都不写入
+	originals := make(map[string][]byte)
+	updated := make(map[string][]byte)
+	for _, file := range resp.Files {
+		content, err := ioutil.ReadFile(filepath.Join(baseDir, file))
+		if err != nil {
+			writeJSONError(w, fmt.Sprintf("无法读取 '%s': %v", file, err), http.StatusInternalServerError)
+			return
+		}
+		newContent, err := renameTagInContent(content, byFile[file], req.New)
+		if err != nil {
+			writeJSONError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err := validateJSONC(newContent); err != nil {
+			writeJSONError(w, fmt.Sprintf("重命名后 '%s' 无法解析：%v", file, err), http.StatusInternalServerError)
+			return
+		}
+		originals[file] = content
+		updated[file] = newContent
+	}
+
+	for _, file := range resp.Files {
+		if _, err := createBackup(file, filepath.Join(baseDir, file)); err != nil {
+			writeJSONError(w, fmt.Sprintf("创建备份失败，已取消重命名：%v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	for i, file := range resp.Files {
+		if err := writeConfigFile(filepath.Join(baseDir, file), updated[file]); err != nil {
+			// 恢复已经写入的文件，保证所有文件要么全部修改要么全部不变
+			for _, written := range resp.Files[:i] {
+				if restoreErr := writeConfigFile(filepath.Join(baseDir, written), originals[written]); restoreErr != nil {
+					log.Printf("重命名标签: 无法恢复 '%s': %v", written, restoreErr)
+				}
+			}
+			writeJSONError(w, fmt.Sprintf("写入 '%s' 失败，已撤销全部修改：%v", file, err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	username := sessionUser(r)
+	if historyEnabled() {
+		if err := commitHistory(resp.Files, username, fmt.Sprintf("重命名%s标签 %s -> %s", tagKindName(req.Kind), req.Old, req.New)); err != nil {
+			log.Printf("版本历史: %v", err)
+		}
+	}
+	log.Printf("用户 '%s' 将%s标签 '%s' 重命名为 '%s'（%d 个文件，%d 处）", username, tagKindName(req.Kind), req.Old, req.New, len(resp.Files), len(resp.Affected))
+
+	resp.Status = "success"
+	resp.Message = fmt.Sprintf("已将 '%s' 重命名为 '%s'，修改了 %d 个文件中的 %d 处。", req.Old, req.New, len(resp.Files), len(resp.Affected))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(resp)
+}
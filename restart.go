@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	singboxServiceName        = "sing-box"
+	defaultRestartGraceSecond = 10
+	// maxRestartGraceSecond 观察期期间一直持有 configWriteMutex，时长必须有上限
+	maxRestartGraceSecond = 300
+)
+
+// SafeRestartRequest ...
+type SafeRestartRequest struct {
+	GraceSeconds int    `json:"grace_seconds,omitempty"` // 覆盖配置中的观察期
+	ProbeAddress string `json:"probe_address,omitempty"` // 覆盖配置中的 TCP 探测地址
+}
+
+// SafeRestartResult 描述一次安全重启的结果
+type SafeRestartResult struct {
+	Status     string `json:"status"` // success / rolled_back / failed
+	Message    string `json:"message"`
+	RolledBack bool   `json:"rolled_back"`
+	Details    string `json:"details,omitempty"` // 失败时附带的服务日志
+}
+
+// lastGoodDir 返回最后已知正常配置集的保存目录: <配置目录>/.sb_editor/last_good/
+func lastGoodDir() (string, error) {
+	baseDir, err := activeConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(baseDir, editorDataDirName, "last_good"), nil
+}
+
+// restartSingbox 执行 systemctl restart
+func restartSingbox() error {
+	output, err := exec.Command("sudo", "systemctl", "restart", singboxServiceName).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("重启服务失败：%v, 详情：%s", err, string(output))
+	}
+	return nil
+}
+
+// singboxIsActive 通过 systemctl is-active 判断服务是否处于运行状态
+func singboxIsActive() bool {
+	output, _ := exec.Command("systemctl", "is-active", singboxServiceName).Output()
+	return strings.TrimSpace(string(output)) == "active"
+}
+
+// probeTCP 尝试连接指定地址，用于确认入站端口已经在监听
+func probeTCP(address string) error {
+	conn, err := net.DialTimeout("tcp", address, 2*time.Second)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// singboxRecentLogs 获取最近的服务日志，帮助定位启动失败的原因
+func singboxRecentLogs() string {
+	output, _ := exec.Command("journalctl", "-u", singboxServiceName, "-n", "30", "--no-pager").CombinedOutput()
+	return string(output)
+}
+
+// waitHealthy 在观察期内每秒检查一次服务状态；观察期结束时若配置了探测地址还要求 TCP 连通
+func waitHealthy(grace time.Duration, probeAddress string) error {
+	deadline := time.Now().Add(grace)
+	for {
+		time.Sleep(time.Second)
+		if !singboxIsActive() {
+			return fmt.Errorf("服务未保持运行状态")
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+	}
+	if probeAddress != "" {
+		if err := probeTCP(probeAddress); err != nil {
+			return fmt.Errorf("TCP 探测 %s 失败: %v", probeAddress, err)
+		}
+	}
+	return nil
+}
+
+// recordLastKnownGood 把活动配置目录中所有 .json 文件保存为最后已知正常配置集。
+// 只在安全重启确认服务健康后调用，调用方持有 configWriteMutex。
+func recordLastKnownGood() error {
+	baseDir, err := activeConfigDir()
+	if err != nil {
+		return err
+	}
+	goodDir, err := lastGoodDir()
+	if err != nil {
+		return err
+	}
+	// 先写到临时目录再整体替换，避免留下半份快照
+	tmpDir := goodDir + ".tmp"
+	os.RemoveAll(tmpDir)
+	if err := os.MkdirAll(tmpDir, 0700); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(baseDir, entry.Name()))
+		if err != nil {
+			os.RemoveAll(tmpDir)
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, entry.Name()), content, 0600); err != nil {
+			os.RemoveAll(tmpDir)
+			return err
+		}
+	}
+	os.RemoveAll(goodDir)
+	return os.Rename(tmpDir, goodDir)
+}
+
+// hasLastKnownGood 判断是否已经记录过最后已知正常配置集
+func hasLastKnownGood() bool {
+	goodDir, err := lastGoodDir()
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(goodDir)
+	return err == nil && info.IsDir()
+}
+
+// restoreLastKnownGood 用最后已知正常配置集覆盖活动目录。
+// 被覆盖的文件会先备份；快照中不存在的 .json 文件移到 .sb_editor/rejected/ 下，避免被 sing-box 读取。
+func restoreLastKnownGood() error {
+	baseDir, err := activeConfigDir()
+	if err != nil {
+		return err
+	}
+	goodDir, err := lastGoodDir()
+	if err != nil {
+		return err
+	}
+	goodEntries, err := os.ReadDir(goodDir)
+	if err != nil {
+		return fmt.Errorf("无法读取最后已知正常配置: %v", err)
+	}
+	goodFiles := make(map[string]bool)
+	for _, entry := range goodEntries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		goodFiles[entry.Name()] = true
+		content, err := ioutil.ReadFile(filepath.Join(goodDir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		filePath := filepath.Join(baseDir, entry.Name())
+		if current, err := ioutil.ReadFile(filePath); err == nil && string(current) == string(content) {
+			continue
+		}
+		if _, err := createBackup(entry.Name(), filePath); err != nil {
+			return err
+		}
+		if err := writeConfigFile(filePath, content); err != nil {
+			return fmt.Errorf("无法恢复 '%s': %v", entry.Name(), err)
+		}
+		recordHistory(entry.Name(), "", "安全重启失败，自动回滚到最后已知正常配置")
+	}
+
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		return err
+	}
+	rejectedDir := filepath.Join(baseDir, editorDataDirName, "rejected", time.Now().Format(backupTimeFormat))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") || goodFiles[entry.Name()] {
+			continue
+		}
+		if err := os.MkdirAll(rejectedDir, 0700); err != nil {
+			return err
+		}
+		if err := os.Rename(filepath.Join(baseDir, entry.Name()), filepath.Join(rejectedDir, entry.Name())); err != nil {
+			return fmt.Errorf("无法移走新增文件 '%s': %v", entry.Name(), err)
+		}
+		log.Printf("回滚: 新增文件 %s 已移到 %s", entry.Name(), rejectedDir)
+	}
+	return nil
+}
+
+// safeRestart 重启服务并在观察期内确认其健康；不健康时自动恢复最后已知正常配置并再次重启
+func safeRestart(grace time.Duration, probeAddress string) SafeRestartResult {
+	configWriteMutex.Lock()
+	defer configWriteMutex.Unlock()
+
+	canRollback := hasLastKnownGood()
+
+	restartErr := restartSingbox()
+	healthErr := restartErr
+	if healthErr == nil {
+		healthErr = waitHealthy(grace, probeAddress)
+	}
+	if healthErr == nil {
+		if err := recordLastKnownGood(); err != nil {
+			log.Printf("无法记录最后已知正常配置: %v", err)
+		}
+		return SafeRestartResult{Status: "success", Message: fmt.Sprintf("Sing-box 已重启并在 %v 内保持健康。", grace)}
+	}
+
+	logs := singboxRecentLogs()
+	log.Printf("安全重启: 服务不健康: %v", healthErr)
+	if !canRollback {
+		return SafeRestartResult{
+			Status:  "failed",
+			Message: fmt.Sprintf("重启后服务不健康（%v），且没有最后已知正常配置，无法自动回滚！", healthErr),
+			Details: logs,
+		}
+	}
+	if err := restoreLastKnownGood(); err != nil {
+		return SafeRestartResult{
+			Status:  "failed",
+			Message: fmt.Sprintf("重启后服务不健康（%v），回滚配置失败：%v", healthErr, err),
+			Details: logs,
+		}
+	}
+	if err := restartSingbox(); err != nil {
+		return SafeRestartResult{
+			Status:     "failed",
+			Message:    fmt.Sprintf("重启后服务不健康（%v），配置已回滚但再次重启失败：%v", healthErr, err),
+			RolledBack: true,
+			Details:    logs,
+		}
+	}
+	if err := waitHealthy(grace, probeAddress); err != nil {
+		return SafeRestartResult{
+			Status:     "failed",
+			Message:    fmt.Sprintf("重启后服务不健康（%v），回滚后服务仍不健康：%v", healthErr, err),
+			RolledBack: true,
+			Details:    logs,
+		}
+	}
+	return SafeRestartResult{
+		Status:     "rolled_back",
+		Message:    fmt.Sprintf("新配置导致服务不健康（%v），已自动恢复最后已知正常配置并重启。失败的文件已备份。", healthErr),
+		RolledBack: true,
+		Details:    logs,
+	}
+}
+
+// safeRestartHandler 处理 /api/safe_restart 请求
+func safeRestartHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, err := activeConfigDir(); err != nil {
+		writeJSONError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	var req SafeRestartRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONError(w, "无效的请求体", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.GraceSeconds < 0 || req.GraceSeconds > maxRestartGraceSecond {
+		writeJSONError(w, fmt.Sprintf("观察期必须在 1 到 %d 秒之间", maxRestartGraceSecond), http.StatusBadRequest)
+		return
+	}
+
+	cfg := getEditorConfig().SafeRestart
+	graceSeconds := cfg.GraceSeconds
+	if req.GraceSeconds > 0 {
+		graceSeconds = req.GraceSeconds
+	}
+	if graceSeconds <= 0 {
+		graceSeconds = defaultRestartGraceSecond
+	}
+	graceSeconds = min(graceSeconds, maxRestartGraceSecond)
+	probeAddress := cfg.ProbeAddress
+	if req.ProbeAddress != "" {
+		probeAddress = req.ProbeAddress
+	}
+	if probeAddress != "" {
+		if _, _, err := net.SplitHostPort(probeAddress); err != nil {
+			writeJSONError(w, fmt.Sprintf("探测地址 '%s' 格式错误，应为 主机:端口", probeAddress), http.StatusBadRequest)
+			return
+		}
+	}
+
+	log.Printf("用户 '%s' 请求安全重启 (观察期 %ds, 探测 %q)", sessionUser(r), graceSeconds, probeAddress)
+	result := safeRestart(time.Duration(graceSeconds)*time.Second, probeAddress)
+
+	statusCode := http.StatusOK
+	if result.Status != "success" {
+		statusCode = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/netip"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// RouteRuleInfo 路由规则列表中的一条规则。规则可以分布在多个文件中，
+// 列表顺序与 sing-box 合并后的匹配顺序一致（先按文件名，再按文件内的顺序）。
+type RouteRuleInfo struct {
+	File     string   `json:"file"`
+	Index    int      `json:"index"` // 在所在文件 route.rules 中的下标
+	Path     string   `json:"path"`  // 在所在文件中的路径，如 route.rules.3
+	Line     int      `json:"line"`
+	Summary  string   `json:"summary"`
+	Action   string   `json:"action"`
+	Outbound string   `json:"outbound,omitempty"`
+	Fields   []string `json:"fields"` // 使用的匹配条件
+	Rule     string   `json:"rule"`   // 规则原文（保留注释）
+}
+
+// routeConfig 从活动目录的所有文件中收集的路由配置
+type routeConfig struct {
+	rules           []RouteRuleInfo
+	values          []gjson.Result // 与 rules 一一对应的规则内容
+	ruleSets        map[string]gjson.Result
+	final           string
+	defaultOutbound string // 第一个出站，没有设置 route.final 时使用
+	etags           map[string]string
+	baseDir         string
+}
+
+// loadRouteConfig 按 sing-box 的合并规则读取路由规则、规则集和默认出站
+func loadRouteConfig() (*routeConfig, error) {
+	baseDir, err := activeConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := listConfigFiles()
+	if err != nil {
+		return nil, err
+	}
+	rc := &routeConfig{ruleSets: make(map[string]gjson.Result), etags: make(map[string]string), baseDir: baseDir}
+	for _, file := range files {
+		content, etag, err := readFileWithETag(filepath.Join(baseDir, file))
+		if err != nil {
+			return nil, fmt.Errorf("无法读取 '%s': %v", file, err)
+		}
+		if err := validateConfigFile(content); err != nil {
+			return nil, fmt.Errorf("'%s' %v", file, err)
+		}
+		rc.etags[file] = etag
+		root := jsoncParse(content)
+		for i, rule := range root.Get("route.rules").Array() {
+			info := RouteRuleInfo{
+				File:    file,
+				Index:   i,
+				Path:    "route.rules." + strconv.Itoa(i),
+				Summary: ruleSummary(rule),
+				Action:  rule.Get("action").String(),
+				Fields:  []string{},
+				Rule:    string(jsoncRaw(content, rule)),
+			}
+			info.Line, _ = offsetPosition(content, rule.Index)
+			if info.Action == "" {
+				info.Action = "route"
+			}
+			if info.Action == "route" {
+				info.Outbound = rule.Get("outbound").String()
+			}
+			rule.ForEach(func(key, _ gjson.Result) bool {
+				if !ruleActionFields[key.Str] {
+					info.Fields = append(info.Fields, key.Str)
+				}
+				return true
+			})
+			rc.rules = append(rc.rules, info)
+			rc.values = append(rc.values, rule)
+		}
+		for _, ruleSet := range root.Get("route.rule_set").Array() {
+			if tag := ruleSet.Get("tag").String(); tag != "" {
+				if _, ok := rc.ruleSets[tag]; !ok {
+					rc.ruleSets[tag] = ruleSet
+				}
+			}
+		}
+		if rc.final == "" {
+			rc.final = root.Get("route.final").String()
+		}
+		if rc.defaultOutbound == "" {
+			rc.defaultOutbound = root.Get("outbounds.0.tag").String()
+		}
+	}
+	return rc, nil
+}
+
+// RouteRulesResponse ...
+type RouteRulesResponse struct {
+	Rules []RouteRuleInfo   `json:"rules"`
+	Final string            `json:"final,omitempty"`
+	ETags map[string]string `json:"etags"` // 各文件的版本号，修改规则时作为 If-Match 回传
+}
+
+// routeRulesHandler 处理 /api/route_rules 请求，按匹配顺序列出所有文件中的路由规则。
+// 增删、排序和编辑规则使用 insert_element / delete_element / move_element / save_content，路径为 route.rules.<下标>。
+func routeRulesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	rc, err := loadRouteConfig()
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	resp := RouteRulesResponse{Rules: rc.rules, Final: rc.final, ETags: rc.etags}
+	if resp.Rules == nil {
+		resp.Rules = []RouteRuleInfo{}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// RouteTestRequest 路由测试的样例连接
+type RouteTestRequest struct {
+	Domain      string `json:"domain,omitempty"`
+	IP          string `json:"ip,omitempty"`
+	Port        int    `json:"port,omitempty"`
+	Network     string `json:"network,omitempty"` // tcp / udp
+	Inbound     string `json:"inbound,omitempty"`
+	ProcessName string `json:"process_name,omitempty"`
+}
+
+// RouteTraceEntry 测试时逐条规则的匹配结果
+type RouteTraceEntry struct {
+	File    string `json:"file"`
+	Index   int    `json:"index"`
+	Summary string `json:"summary"`
+	Result  string `json:"result"` // matched / no_match / skipped（含无法本地判断的条件）/ continue（命中非终止动作）
+	Note    string `json:"note,omitempty"`
+}
+
+// RouteTestResponse ...
+type RouteTestResponse struct {
+	Matched  *RouteRuleInfo    `json:"matched"` // 命中的规则，没有命中时为 null
+	Action   string            `json:"action"`
+	Outbound string            `json:"outbound,omitempty"`
+	Final    bool              `json:"final"` // 没有规则命中，使用 route.final 或第一个出站
+	Trace    []RouteTraceEntry `json:"trace"`
+}
+
+// routeMatcher 在本地按 sing-box 的规则语义匹配样例连接。
+// 只支持能从样例判断的条件；其他条件（protocol、geosite、source_ip_cidr 等）记录在 unsupported 中。
+type routeMatcher struct {
+	sample      RouteTestRequest
+	addr        netip.Addr
+	rc          *routeConfig
+	localSets   map[string][]gjson.Result
+	unsupported []string
+}
+
+// nonFinalActions 命中后继续匹配后续规则的动作
+var nonFinalActions = map[string]bool{"route-options": true, "sniff": true, "resolve": true}
+
+// listValues 返回可以写成单个值或数组的字段的所有取值
+func listValues(value gjson.Result) []gjson.Result {
+	if value.IsArray() {
+		return value.Array()
+	}
+	return []gjson.Result{value}
+}
+
+func anyValue(value gjson.Result, match func(item gjson.Result) bool) bool {
+	for _, item := range listValues(value) {
+		if match(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// domainSuffixMatch 与 sing-box 一致："google.com" 匹配 google.com 及其子域名，".google.com" 只匹配子域名
+func domainSuffixMatch(domain, suffix string) bool {
+	suffix = strings.ToLower(suffix)
+	if strings.HasPrefix(suffix, ".") {
+		return strings.HasSuffix(domain, suffix)
+	}
+	return domain == suffix || strings.HasSuffix(domain, "."+suffix)
+}
+
+// portRangeMatch 匹配 "1000:2000"、":3000"、"4000:" 形式的端口范围
+func portRangeMatch(port int, portRange string) bool {
+	parts := strings.SplitN(portRange, ":", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	low, high := 0, 65535
+	if parts[0] != "" {
+		n, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return false
+		}
+		low = n
+	}
+	if parts[1] != "" {
+		n, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return false
+		}
+		high = n
+	}
+	return port >= low && port <= high
+}
+
+// isPrivateAddr 与 sing-box 的 ip_is_private 一致：非公网地址
+func isPrivateAddr(addr netip.Addr) bool {
+	return addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified()
+}
+
+// ruleMatchState 一条规则的目标地址组、目标端口组是否已经命中。
+// 与 sing-box 相同，规则集中的规则与引用它的规则共享这一状态：规则集的地址类条件并入外层规则的地址组，端口类条件并入端口组。
+type ruleMatchState struct {
+	addressMatched bool
+	portMatched    bool
+}
+
+// matchRule 匹配一条普通规则或逻辑规则
+func (m *routeMatcher) matchRule(rule gjson.Result, st *ruleMatchState) bool {
+	if rule.Get("type").String() == "logical" {
+		and := rule.Get("mode").String() == "and"
+		matched := and
+		for _, sub := range rule.Get("rules").Array() {
+			if m.matchRule(sub, &ruleMatchState{}) != and {
+				matched = !and
+				break
+			}
+		}
+		return matched != rule.Get("invert").Bool()
+	}
+	return m.matchDefaultRule(rule, st) != rule.Get("invert").Bool()
+}
+
+// matchDefaultRule 匹配普通规则。与 sing-box 相同，目标地址类条件（domain*、ip_cidr、ip_is_private）之间、
+// 目标端口类条件（port、port_range）之间是“或”，各组与其余条件之间是“与”；rule_set 的条件并入这两组（见 ruleMatchState）。
+func (m *routeMatcher) matchDefaultRule(rule gjson.Result, st *ruleMatchState) bool {
+	domain := strings.TrimSuffix(strings.ToLower(m.sample.Domain), ".")
+	hasAddressItem, addressMatched := false, false
+	hasPortItem, portMatched := false, false
+	matched := true
+	var ruleSets []gjson.Result
+	rule.ForEach(func(key, value gjson.Result) bool {
+		switch key.Str {
+		case "domain":
+			hasAddressItem = true
+			addressMatched = addressMatched || domain != "" && anyValue(value, func(item gjson.Result) bool {
+				return strings.EqualFold(item.String(), domain)
+			})
+		case "domain_suffix":
+			hasAddressItem = true
+			addressMatched = addressMatched || domain != "" && anyValue(value, func(item gjson.Result) bool {
+				return domainSuffixMatch(domain, item.String())
+			})
+		case "domain_keyword":
+			hasAddressItem = true
+			addressMatched = addressMatched || domain != "" && anyValue(value, func(item gjson.Result) bool {
+				return strings.Contains(domain, strings.ToLower(item.String()))
+			})
+		case "domain_regex":
+			hasAddressItem = true
+			addressMatched = addressMatched || domain != "" && anyValue(value, func(item gjson.Result) bool {
+				re, err := regexp.Compile(item.String())
+				return err == nil && re.MatchString(domain)
+			})
+		case "ip_cidr":
+			hasAddressItem = true
+			addressMatched = addressMatched || m.addr.IsValid() && anyValue(value, func(item gjson.Result) bool {
+				if prefix, err := netip.ParsePrefix(item.String()); err == nil {
+					return prefix.Contains(m.addr)
+				}
+				addr, err := netip.ParseAddr(item.String())
+				return err == nil && addr == m.addr
+			})
+		case "ip_is_private":
+			hasAddressItem = true
+			addressMatched = addressMatched || m.addr.IsValid() && value.Bool() && isPrivateAddr(m.addr)
+		case "port":
+			hasPortItem = true
+			portMatched = portMatched || anyValue(value, func(item gjson.Result) bool { return int(item.Int()) == m.sample.Port })
+		case "port_range":
+			hasPortItem = true
+			portMatched = portMatched || anyValue(value, func(item gjson.Result) bool { return portRangeMatch(m.sample.Port, item.String()) })
+		case "network":
+			matched = matched && anyValue(value, func(item gjson.Result) bool { return item.String() == m.sample.Network })
+		case "inbound":
+			matched = matched && anyValue(value, func(item gjson.Result) bool { return item.String() == m.sample.Inbound })
+		case "process_name":
+			matched = matched && anyValue(value, func(item gjson.Result) bool { return item.String() == m.sample.ProcessName })
+		case "ip_version":
+			version := value.Int()
+			matched = matched && m.addr.IsValid() && (version == 4 && m.addr.Is4() || version == 6 && m.addr.Is6())
+		case "rule_set":
+			// 规则集要在本规则的地址组、端口组都判断完之后再匹配
+			ruleSets = append(ruleSets, value)
+		case "rule_set_ip_cidr_accept_empty":
+		default:
+			if !ruleActionFields[key.Str] {
+				m.unsupported = append(m.unsupported, key.Str)
+				matched = false
+			}
+		}
+		return true
+	})
+	st.addressMatched = st.addressMatched || hasAddressItem && addressMatched
+	st.portMatched = st.portMatched || hasPortItem && portMatched
+	for _, value := range ruleSets {
+		matched = matched && anyValue(value, func(item gjson.Result) bool { return m.matchRuleSet(item.String(), st) })
+	}
+	return matched && (!hasAddressItem || st.addressMatched) && (!hasPortItem || st.portMatched)
+}
+
+// matchRuleSet 匹配规则集中的任意一条规则。支持内联规则集和源文件格式（JSON）的本地规则集，
+// 远程规则集和二进制规则集无法在本地判断。命中的规则在 st 中记录地址组、端口组的命中状态。
+func (m *routeMatcher) matchRuleSet(tag string, st *ruleMatchState) bool {
+	rules, ok := m.localSets[tag]
+	if !ok {
+		var err error
+		rules, err = m.loadRuleSet(tag)
+		if err != nil {
+			m.unsupported = append(m.unsupported, fmt.Sprintf("rule_set %s（%v）", tag, err))
+			return false
+		}
+		m.localSets[tag] = rules
+	}
+	for _, rule := range rules {
+		inner := *st
+		if m.matchRule(rule, &inner) {
+			*st = inner
+			return true
+		}
+	}
+	return false
+}
+
+func (m *routeMatcher) loadRuleSet(tag string) ([]gjson.Result, error) {
+	ruleSet, ok := m.rc.ruleSets[tag]
+	if !ok {
+		return nil, fmt.Errorf("未定义")
+	}
+	switch ruleSet.Get("type").String() {
+	case "inline":
+		return ruleSet.Get("rules").Array(), nil
+	case "local":
+		path := ruleSet.Get("path").String()
+		if ruleSet.Get("format").String() == "binary" || strings.HasSuffix(path, ".srs") {
+			return nil, fmt.Errorf("二进制格式")
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(m.rc.baseDir, path)
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("无法读取 %s", path)
+		}
+		if err := validateJSONC(content); err != nil {
+			return nil, fmt.Errorf("格式错误")
+		}
+		return jsoncParse(content).Get("rules").Array(), nil
+	}
+	return nil, fmt.Errorf("远程规则集")
+}
+
+// testRouteHandler 处理 /api/test_route 请求：用样例连接（域名、IP、端口、网络、入站、进程名）
+// 在本地逐条匹配路由规则，返回第一条命中的规则及其出站，以及每条规则的匹配过程。
+func testRouteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	var req RouteTestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "无效的请求体", http.StatusBadRequest)
+		return
+	}
+	req.Domain = strings.TrimSpace(req.Domain)
+	req.IP = strings.TrimSpace(req.IP)
+	if req.Domain == "" && req.IP == "" {
+		writeJSONError(w, "需要指定域名或 IP", http.StatusBadRequest)
+		return
+	}
+	if req.Network != "" && req.Network != "tcp" && req.Network != "udp" {
+		writeJSONError(w, "network 只能是 tcp 或 udp", http.StatusBadRequest)
+		return
+	}
+	m := &routeMatcher{sample: req, localSets: make(map[string][]gjson.Result)}
+	if req.IP != "" {
+		addr, err := netip.ParseAddr(req.IP)
+		if err != nil {
+			writeJSONError(w, fmt.Sprintf("无效的 IP 地址 '%s'", req.IP), http.StatusBadRequest)
+			return
+		}
+		m.addr = addr.Unmap()
+	}
+	rc, err := loadRouteConfig()
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	m.rc = rc
+
+	resp := RouteTestResponse{Trace: []RouteTraceEntry{}}
+	for i, rule := range rc.values {
+		info := rc.rules[i]
+		entry := RouteTraceEntry{File: info.File, Index: info.Index, Summary: info.Summary, Result: "no_match"}
+		m.unsupported = nil
+		matched := m.matchRule(rule, &ruleMatchState{})
+		switch {
+		case len(m.unsupported) > 0:
+			entry.Result = "skipped"
+			entry.Note = "包含无法本地判断的条件: " + strings.Join(m.unsupported, ", ")
+		case matched && nonFinalActions[info.Action]:
+			entry.Result = "continue"
+			entry.Note = fmt.Sprintf("%s 不是终止动作，继续匹配后续规则", info.Action)
+		case matched:
+			entry.Result = "matched"
+		}
+		resp.Trace = append(resp.Trace, entry)
+		if entry.Result == "matched" {
+			resp.Matched = &rc.rules[i]
+			resp.Action = info.Action
+			resp.Outbound = info.Outbound
+			break
+		}
+	}
+	if resp.Matched == nil {
+		resp.Final = true
+		resp.Action = "route"
+		resp.Outbound = rc.final
+		if resp.Outbound == "" {
+			resp.Outbound = rc.defaultOutbound
+		}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestRouteMatcher(t *testing.T) {
+	ruleSets := map[string]gjson.Result{
+		"geosite-x": gjson.Parse(`{"type": "inline", "tag": "geosite-x", "rules": [{"domain_suffix": "x.com"}, {"domain": "exact.net"}]}`),
+		"ports":     gjson.Parse(`{"type": "inline", "tag": "ports", "rules": [{"port": 8443}]}`),
+		"mixed":     gjson.Parse(`{"type": "inline", "tag": "mixed", "rules": [{"ip_cidr": "10.0.0.0/8", "network": "udp"}]}`),
+		"remote":    gjson.Parse(`{"type": "remote", "tag": "remote", "url": "https://example.com/r.srs"}`),
+	}
+	tests := []struct {
+		name        string
+		rule        string
+		sample      RouteTestRequest
+		want        bool
+		unsupported bool
+	}{
+		// 地址类条件之间是“或”
+		{"domain or ip_cidr, domain", `{"domain_suffix": "a.com", "ip_cidr": "1.1.1.0/24"}`, RouteTestRequest{Domain: "www.a.com"}, true, false},
+		{"domain or ip_cidr, ip", `{"domain_suffix": "a.com", "ip_cidr": "1.1.1.0/24"}`, RouteTestRequest{IP: "1.1.1.1"}, true, false},
+		{"domain or ip_cidr, neither", `{"domain_suffix": "a.com", "ip_cidr": "1.1.1.0/24"}`, RouteTestRequest{Domain: "b.com", IP: "2.2.2.2"}, false, false},
+		// 地址组与端口组、其他条件之间是“与”
+		{"address and port", `{"domain": "a.com", "port": [80, 443]}`, RouteTestRequest{Domain: "a.com", Port: 443}, true, false},
+		{"address and wrong port", `{"domain": "a.com", "port": 443}`, RouteTestRequest{Domain: "a.com", Port: 80}, false, false},
+		{"port or port_range", `{"port": 80, "port_range": "1000:2000"}`, RouteTestRequest{Domain: "a.com", Port: 1500}, true, false},
+		{"address and network", `{"domain": "a.com", "network": "udp"}`, RouteTestRequest{Domain: "a.com", Network: "tcp"}, false, false},
+		{"ip_is_private", `{"ip_is_private": true}`, RouteTestRequest{IP: "192.168.1.1"}, true, false},
+		{"invert", `{"domain": "a.com", "invert": true}`, RouteTestRequest{Domain: "b.com"}, true, false},
+		// rule_set 的地址类条件并入外层规则的地址组
+		{"rule_set only", `{"rule_set": "geosite-x"}`, RouteTestRequest{Domain: "www.x.com"}, true, false},
+		{"rule_set miss", `{"rule_set": "geosite-x"}`, RouteTestRequest{Domain: "y.com"}, false, false},
+		{"outer domain with rule_set", `{"domain_suffix": "a.com", "rule_set": "geosite-x"}`, RouteTestRequest{Domain: "a.com"}, true, false},
+		{"rule_set domain with outer domain", `{"domain_suffix": "a.com", "rule_set": "geosite-x"}`, RouteTestRequest{Domain: "exact.net"}, true, false},
+		{"neither outer nor rule_set", `{"domain_suffix": "a.com", "rule_set": "geosite-x"}`, RouteTestRequest{Domain: "b.com"}, false, false},
+		{"rule_set with other condition", `{"rule_set": "geosite-x", "network": "tcp"}`, RouteTestRequest{Domain: "x.com", Network: "udp"}, false, false},
+		{"rule_set port group", `{"port": 443, "rule_set": "ports"}`, RouteTestRequest{Domain: "a.com", Port: 8443}, true, false},
+		{"rule_set port miss", `{"port": 443, "rule_set": "ports"}`, RouteTestRequest{Domain: "a.com", Port: 80}, false, false},
+		{"rule_set inner and", `{"rule_set": "mixed"}`, RouteTestRequest{IP: "10.1.1.1", Network: "tcp"}, false, false},
+		{"rule_set list", `{"rule_set": ["ports", "geosite-x"]}`, RouteTestRequest{Domain: "x.com", Port: 80}, true, false},
+		{"remote rule_set", `{"rule_set": "remote"}`, RouteTestRequest{Domain: "x.com"}, false, true},
+		// 逻辑规则
+		{"logical and", `{"type": "logical", "mode": "and", "rules": [{"domain": "a.com"}, {"port": 443}]}`, RouteTestRequest{Domain: "a.com", Port: 443}, true, false},
+		{"logical and miss", `{"type": "logical", "mode": "and", "rules": [{"domain": "a.com"}, {"port": 443}]}`, RouteTestRequest{Domain: "a.com", Port: 80}, false, false},
+		{"logical or", `{"type": "logical", "mode": "or", "rules": [{"domain": "a.com"}, {"port": 443}]}`, RouteTestRequest{Domain: "b.com", Port: 443}, true, false},
+		{"logical or miss", `{"type": "logical", "mode": "or", "rules": [{"domain": "a.com"}, {"port": 443}]}`, RouteTestRequest{Domain: "b.com", Port: 80}, false, false},
+		{"unsupported field", `{"protocol": "tls"}`, RouteTestRequest{Domain: "a.com"}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &routeMatcher{
+				sample:    tt.sample,
+				rc:        &routeConfig{ruleSets: ruleSets},
+				localSets: make(map[string][]gjson.Result),
+			}
+			if tt.sample.IP != "" {
+				m.addr = netip.MustParseAddr(tt.sample.IP)
+			}
+			got := m.matchRule(gjson.Parse(tt.rule), &ruleMatchState{})
+			if len(m.unsupported) > 0 != tt.unsupported {
+				t.Errorf("unsupported = %q, want %v", m.unsupported, tt.unsupported)
+			}
+			if got != tt.want {
+				t.Errorf("matchRule(%s) = %v, want %v", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
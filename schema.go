@@ -0,0 +1,585 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// FieldSchema 由 schema_types.go 中的 Go 结构体反射得到的字段描述，用于结构校验
+type FieldSchema struct {
+	Name       string         `json:"name,omitempty"`
+	Kind       string         `json:"kind"` // object / array / map / string / duration / integer / number / boolean / string_list / integer_list / any
+	Required   bool           `json:"required,omitempty"`
+	Deprecated bool           `json:"deprecated,omitempty"`
+	Enum       []string       `json:"enum,omitempty"`
+	Help       string         `json:"help,omitempty"`
+	Unsigned   bool           `json:"unsigned,omitempty"`
+	Max        uint64         `json:"max,omitempty"`      // 无符号整数的上限，0 表示不限制
+	Elem       *FieldSchema   `json:"elem,omitempty"`     // array / map / 列表的元素
+	Fields     []*FieldSchema `json:"fields,omitempty"`   // object 的字段
+	Variants   string         `json:"variants,omitempty"` // 多态对象：按区分字段在 schemaVariants 中选择结构
+}
+
+// VariantSet 一组按区分字段（通常是 type）选择结构的多态对象
+type VariantSet struct {
+	Key     string                 // 区分字段
+	Default interface{}            // 缺少区分字段时使用的结构，nil 表示区分字段必填
+	Types   map[string]interface{} // 区分字段取值 -> 结构体样例
+}
+
+// schemaVariants 配置中所有多态对象的结构集合
+var schemaVariants = map[string]*VariantSet{
+	"inbound": {Key: "type", Types: map[string]interface{}{
+		"direct":      DirectInbound{},
+		"mixed":       MixedInbound{},
+		"socks":       MixedInbound{},
+		"http":        HTTPInbound{},
+		"shadowsocks": ShadowsocksInbound{},
+		"vmess":       VMessInbound{},
+		"trojan":      TrojanInbound{},
+		"naive":       NaiveInbound{},
+		"hysteria":    HysteriaInbound{},
+		"shadowtls":   ShadowTLSInbound{},
+		"tuic":        TUICInbound{},
+		"hysteria2":   Hysteria2Inbound{},
+		"vless":       VLESSInbound{},
+		"anytls":      AnyTLSInbound{},
+		"tun":         TunInbound{},
+		"redirect":    RedirectInbound{},
+		"tproxy":      RedirectInbound{},
+	}},
+	"outbound": {Key: "type", Types: map[string]interface{}{
+		"direct":      DirectOutbound{},
+		"block":       BlockOutbound{},
+		"dns":         BlockOutbound{},
+		"socks":       SOCKSOutbound{},
+		"http":        HTTPOutbound{},
+		"shadowsocks": ShadowsocksOutbound{},
+		"vmess":       VMessOutbound{},
+		"trojan":      TrojanOutbound{},
+		"wireguard":   WireGuardOutbound{},
+		"hysteria":    HysteriaOutbound{},
+		"vless":       VLESSOutbound{},
+		"shadowtls":   ShadowTLSOutbound{},
+		"tuic":        TUICOutbound{},
+		"hysteria2":   Hysteria2Outbound{},
+		"anytls":      AnyTLSOutbound{},
+		"tor":         TorOutbound{},
+		"ssh":         SSHOutbound{},
+		"selector":    SelectorOutbound{},
+		"urltest":     URLTestOutbound{},
+	}},
+	"endpoint": {Key: "type", Types: map[string]interface{}{
+		"wireguard": WireGuardEndpoint{},
+		"tailscale": TailscaleEndpoint{},
+	}},
+	"transport": {Key: "type", Types: map[string]interface{}{
+		"http":        HTTPTransport{},
+		"ws":          WebsocketTransport{},
+		"quic":        QUICTransport{},
+		"grpc":        GRPCTransport{},
+		"httpupgrade": HTTPUpgradeTransport{},
+	}},
+	"dns_server": {Key: "type", Default: LegacyDNSServer{}, Types: map[string]interface{}{
+		"local":     LocalDNSServer{},
+		"hosts":     HostsDNSServer{},
+		"tcp":       RemoteDNSServer{},
+		"udp":       RemoteDNSServer{},
+		"tls":       TLSDNSServer{},
+		"quic":      TLSDNSServer{},
+		"https":     HTTPSDNSServer{},
+		"h3":        HTTPSDNSServer{},
+		"dhcp":      DHCPDNSServer{},
+		"fakeip":    FakeIPDNSServer{},
+		"tailscale": TailscaleDNSServer{},
+		"resolved":  ResolvedDNSServer{},
+	}},
+	"dns_rule": {Key: "type", Default: DefaultDNSRule{}, Types: map[string]interface{}{
+		"default": DefaultDNSRule{},
+		"logical": LogicalDNSRule{},
+	}},
+	"route_rule": {Key: "type", Default: DefaultRouteRule{}, Types: map[string]interface{}{
+		"default": DefaultRouteRule{},
+		"logical": LogicalRouteRule{},
+	}},
+	"rule_set": {Key: "type", Types: map[string]interface{}{
+		"inline": InlineRuleSet{},
+		"local":  LocalRuleSet{},
+		"remote": RemoteRuleSet{},
+	}},
+}
+
+// variantTypeNames 返回多态对象所有可用的区分字段取值（已排序）
+func variantTypeNames(set *VariantSet) []string {
+	names := make([]string, 0, len(set.Types))
+	for name := range set.Types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var (
+	durationType   = reflect.TypeOf(Duration(""))
+	stringListType = reflect.TypeOf(StringList(nil))
+	uint16ListType = reflect.TypeOf(Uint16List(nil))
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+	schemaCache      = make(map[reflect.Type]*FieldSchema)
+	schemaCacheMutex sync.Mutex
+)
+
+// schemaOf 返回结构体样例对应的描述
+func schemaOf(sample interface{}) *FieldSchema {
+	schemaCacheMutex.Lock()
+	defer schemaCacheMutex.Unlock()
+	return schemaForType(reflect.TypeOf(sample))
+}
+
+// schemaForType 反射生成类型的描述，结果按类型缓存。调用方必须持有 schemaCacheMutex。
+func schemaForType(t reflect.Type) *FieldSchema {
+	if cached, ok := schemaCache[t]; ok {
+		return cached
+	}
+	var schema *FieldSchema
+	switch {
+	case t == durationType:
+		schema = &FieldSchema{Kind: "duration"}
+	case t == stringListType:
+		schema = &FieldSchema{Kind: "string_list", Elem: &FieldSchema{Kind: "string"}}
+	case t == uint16ListType:
+		schema = &FieldSchema{Kind: "integer_list", Elem: &FieldSchema{Kind: "integer", Unsigned: true, Max: math.MaxUint16}}
+	case t == rawMessageType:
+		schema = &FieldSchema{Kind: "any"}
+	default:
+		switch t.Kind() {
+		case reflect.Ptr:
+			return schemaForType(t.Elem())
+		case reflect.Struct:
+			schema = &FieldSchema{Kind: "object", Fields: structFields(t)}
+		case reflect.Slice, reflect.Array:
+			schema = &FieldSchema{Kind: "array", Elem: schemaForType(t.Elem())}
+		case reflect.Map:
+			schema = &FieldSchema{Kind: "map", Elem: schemaForType(t.Elem())}
+		case reflect.String:
+			schema = &FieldSchema{Kind: "string"}
+		case reflect.Bool:
+			schema = &FieldSchema{Kind: "boolean"}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			schema = &FieldSchema{Kind: "integer"}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			schema = &FieldSchema{Kind: "integer", Unsigned: true}
+			if t.Bits() < 64 {
+				schema.Max = 1<<uint(t.Bits()) - 1
+			}
+		case reflect.Float32, reflect.Float64:
+			schema = &FieldSchema{Kind: "number"}
+		default:
+			schema = &FieldSchema{Kind: "any"}
+		}
+	}
+	schemaCache[t] = schema
+	return schema
+}
+
+// structFields 收集结构体的字段描述，匿名嵌入的结构体字段被展开（与 encoding/json 的行为一致）
+func structFields(t reflect.Type) []*FieldSchema {
+	var fields []*FieldSchema
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (f.PkgPath != "" && !f.Anonymous) {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" {
+			fields = append(fields, structFields(f.Type)...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		field := *schemaForType(f.Type)
+		field.Name = name
+		field.Required = f.Tag.Get("required") == "true"
+		field.Deprecated = f.Tag.Get("deprecated") == "true"
+		field.Help = f.Tag.Get("help")
+		if enum := f.Tag.Get("enum"); enum != "" {
+			field.Enum = strings.Split(enum, ",")
+		}
+		if variants := f.Tag.Get("variants"); variants != "" {
+			variantField := &FieldSchema{Kind: "object", Variants: variants}
+			if field.Kind == "array" {
+				field.Elem = variantField
+			} else {
+				field.Kind, field.Variants = variantField.Kind, variantField.Variants
+			}
+		}
+		fields = append(fields, &field)
+	}
+	return fields
+}
+
+// SchemaIssue 结构校验发现的一个问题，Path 为 gjson 风格的路径（数组用下标）
+type SchemaIssue struct {
+	File     string `json:"file,omitempty"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"` // error / warning
+	Message  string `json:"message"`
+}
+
+// schemaValidator 在 JSONC 原文上遍历并收集问题
+type schemaValidator struct {
+	file    string
+	content []byte
+	issues  []SchemaIssue
+}
+
+func (v *schemaValidator) add(value gjson.Result, path, severity, format string, args ...interface{}) {
+	line, column := offsetPosition(v.content, value.Index)
+	v.issues = append(v.issues, SchemaIssue{
+		File:     v.file,
+		Path:     path,
+		Line:     line,
+		Column:   column,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// parseDuration 与 sing-box 相同，在 time.ParseDuration 的基础上支持以天为单位的 d，如 "1d"、"1d12h"
+func parseDuration(s string) (time.Duration, error) {
+	days, rest, ok := strings.Cut(s, "d")
+	if !ok {
+		return time.ParseDuration(s)
+	}
+	n, err := strconv.ParseFloat(days, 64)
+	if err != nil || n < 0 || strings.ContainsAny(days, "eE") {
+		return 0, fmt.Errorf("无效的时长 %q", s)
+	}
+	d := time.Duration(n * float64(24*time.Hour))
+	if rest != "" {
+		extra, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, err
+		}
+		d += extra
+	}
+	return d, nil
+}
+
+// joinJSONPath 拼接 gjson 路径，键中的特殊字符会被转义
+func joinJSONPath(parent, key string) string {
+	if parent == "" {
+		return gjson.Escape(key)
+	}
+	return parent + "." + gjson.Escape(key)
+}
+
+// validateSchema 按内置结构校验一个配置文件，返回发现的问题；语法错误作为单个问题返回
+func validateSchema(file string, content []byte) []SchemaIssue {
+	if err := validateJSONC(content); err != nil {
+		syntaxErr := err.(*JSONSyntaxError)
+		return []SchemaIssue{{File: file, Line: syntaxErr.Line, Column: syntaxErr.Column, Severity: "error", Message: "JSON 格式错误: " + syntaxErr.Message}}
+	}
+	v := &schemaValidator{file: file, content: content}
+	root := jsoncParse(content)
+	if !root.IsObject() {
+		v.add(root, "", "error", "配置文件的根节点必须是 JSON 对象")
+		return v.issues
+	}
+	v.walkObject(root, schemaOf(SingboxConfig{}).Fields, "", "")
+	return v.issues
+}
+
+func (v *schemaValidator) walk(value gjson.Result, schema *FieldSchema, path string) {
+	// null 等同于未设置
+	if value.Type == gjson.Null {
+		return
+	}
+	switch schema.Kind {
+	case "any":
+	case "string":
+		if value.Type != gjson.String {
+			v.add(value, path, "error", "应为字符串")
+			return
+		}
+		v.checkEnum(value, schema, path, value.Str)
+	case "duration":
+		if value.Type != gjson.String {
+			v.add(value, path, "error", "应为时长字符串，如 \"10s\"")
+			return
+		}
+		if _, err := parseDuration(value.Str); err != nil && value.Str != "" {
+			v.add(value, path, "error", "无效的时长 %q，应形如 \"10s\"、\"1m30s\"、\"1d\"", value.Str)
+		}
+	case "integer":
+		v.checkInteger(value, schema, path)
+	case "number":
+		if value.Type != gjson.Number {
+			v.add(value, path, "error", "应为数字")
+		}
+	case "boolean":
+		if value.Type != gjson.True && value.Type != gjson.False {
+			v.add(value, path, "error", "应为 true 或 false")
+		}
+	case "string_list", "integer_list":
+		if !value.IsArray() {
+			// 单个值等同于只有一个元素的列表
+			v.walk(value, schema.withEnum(schema.Elem), path)
+			return
+		}
+		value.ForEach(func(key, item gjson.Result) bool {
+			v.walk(item, schema.withEnum(schema.Elem), joinJSONPath(path, strconv.Itoa(int(key.Num))))
+			return true
+		})
+	case "array":
+		if !value.IsArray() {
+			v.add(value, path, "error", "应为数组")
+			return
+		}
+		value.ForEach(func(key, item gjson.Result) bool {
+			v.walk(item, schema.Elem, joinJSONPath(path, strconv.Itoa(int(key.Num))))
+			return true
+		})
+	case "map":
+		if !value.IsObject() {
+			v.add(value, path, "error", "应为对象")
+			return
+		}
+		value.ForEach(func(key, item gjson.Result) bool {
+			v.walk(item, schema.Elem, joinJSONPath(path, key.Str))
+			return true
+		})
+	case "object":
+		if schema.Variants != "" {
+			v.walkVariant(value, schemaVariants[schema.Variants], path)
+		} else {
+			v.walkObject(value, schema.Fields, path, "")
+		}
+	}
+}
+
+// withEnum 让列表元素继承列表字段上声明的可选值
+func (s *FieldSchema) withEnum(elem *FieldSchema) *FieldSchema {
+	if len(s.Enum) == 0 {
+		return elem
+	}
+	copied := *elem
+	copied.Enum = s.Enum
+	return &copied
+}
+
+func (v *schemaValidator) checkEnum(value gjson.Result, schema *FieldSchema, path, text string) {
+	if len(schema.Enum) == 0 || text == "" {
+		return
+	}
+	for _, allowed := range schema.Enum {
+		if text == allowed {
+			return
+		}
+	}
+	v.add(value, path, "error", "无效的取值 %q，可选值: %s", text, strings.Join(schema.Enum, ", "))
+}
+
+func (v *schemaValidator) checkInteger(value gjson.Result, schema *FieldSchema, path string) {
+	if value.Type != gjson.Number || value.Num != math.Trunc(value.Num) {
+		v.add(value, path, "error", "应为整数")
+		return
+	}
+	if schema.Unsigned && value.Num < 0 {
+		v.add(value, path, "error", "不能为负数")
+		return
+	}
+	if schema.Max != 0 && value.Num > float64(schema.Max) {
+		v.add(value, path, "error", "超出范围，最大为 %d", schema.Max)
+		return
+	}
+	v.checkEnum(value, schema, path, strconv.FormatInt(int64(value.Num), 10))
+}
+
+// walkObject 校验对象的必填字段、未知字段和每个字段的值。skipKey 为已由多态选择校验过的区分字段。
+func (v *schemaValidator) walkObject(value gjson.Result, fields []*FieldSchema, path, skipKey string) {
+	if !value.IsObject() {
+		v.add(value, path, "error", "应为对象")
+		return
+	}
+	byName := make(map[string]*FieldSchema, len(fields))
+	for _, field := range fields {
+		byName[field.Name] = field
+		if field.Required && !value.Get(gjson.Escape(field.Name)).Exists() {
+			v.add(value, path, "error", "缺少必填字段 '%s'", field.Name)
+		}
+	}
+	value.ForEach(func(key, item gjson.Result) bool {
+		itemPath := joinJSONPath(path, key.Str)
+		field, ok := byName[key.Str]
+		if !ok {
+			if path == "" {
+				v.add(item, itemPath, "warning", "未知的顶层配置项 '%s'，sing-box 将拒绝加载", key.Str)
+			} else {
+				v.add(item, itemPath, "warning", "未知字段 '%s'", key.Str)
+			}
+			return true
+		}
+		if field.Deprecated {
+			message := fmt.Sprintf("字段 '%s' 已弃用", key.Str)
+			if field.Help != "" {
+				message += "：" + field.Help
+			}
+			v.add(item, itemPath, "warning", "%s", message)
+		}
+		if key.Str != skipKey {
+			v.walk(item, field, itemPath)
+		}
+		return true
+	})
+}
+
+// walkVariant 根据区分字段选择结构后校验多态对象
+func (v *schemaValidator) walkVariant(value gjson.Result, set *VariantSet, path string) {
+	if !value.IsObject() {
+		v.add(value, path, "error", "应为对象")
+		return
+	}
+	discriminator := value.Get(set.Key)
+	sample := set.Default
+	if discriminator.Exists() {
+		if discriminator.Type != gjson.String {
+			v.add(discriminator, joinJSONPath(path, set.Key), "error", "应为字符串")
+			return
+		}
+		var ok bool
+		if sample, ok = set.Types[discriminator.Str]; !ok {
+			v.add(discriminator, joinJSONPath(path, set.Key), "error", "未知的类型 %q，可选值: %s",
+				discriminator.Str, strings.Join(variantTypeNames(set), ", "))
+			return
+		}
+	} else if sample == nil {
+		v.add(value, path, "error", "缺少必填字段 '%s'", set.Key)
+		return
+	}
+	v.walkObject(value, schemaOf(sample).Fields, path, set.Key)
+}
+
+// SchemaValidateRequest ...
+type SchemaValidateRequest struct {
+	Filename string `json:"filename"`
+	Content  string `json:"content"`
+}
+
+// SchemaValidateResponse ...
+type SchemaValidateResponse struct {
+	Issues   []SchemaIssue `json:"issues"`
+	Errors   int           `json:"errors"`
+	Warnings int           `json:"warnings"`
+}
+
+// validateSchemaHandler 处理 /api/validate_schema 请求，不依赖 sing-box 可执行文件。
+// GET ?filename=... 校验磁盘上的文件（省略 filename 时校验目录中的所有文件）；
+// POST {filename, content} 校验编辑器中尚未保存的内容，行列号对应提交的内容。
+func validateSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	resp := SchemaValidateResponse{Issues: []SchemaIssue{}}
+	switch r.Method {
+	case http.MethodGet:
+		var files []string
+		if filename := r.URL.Query().Get("filename"); filename != "" {
+			files = []string{filename}
+		} else {
+			var err error
+			if files, err = listConfigFiles(); err != nil {
+				writeJSONError(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+		}
+		for _, filename := range files {
+			filePath, err := validateFilename(filename)
+			if err != nil {
+				writeJSONError(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			content, err := ioutil.ReadFile(filePath)
+			if err != nil {
+				writeJSONError(w, fmt.Sprintf("无法读取文件 '%s': %v", filename, err), http.StatusInternalServerError)
+				return
+			}
+			resp.Issues = append(resp.Issues, validateSchema(filename, content)...)
+		}
+	case http.MethodPost:
+		var req SchemaValidateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONError(w, "无效的请求体", http.StatusBadRequest)
+			return
+		}
+		resp.Issues = validateSchema(req.Filename, []byte(req.Content))
+	default:
+		writeJSONError(w, "只支持 GET 或 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	for _, issue := range resp.Issues {
+		if issue.Severity == "error" {
+			resp.Errors++
+		} else {
+			resp.Warnings++
+		}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// FieldSchemaResponse ...
+type FieldSchemaResponse struct {
+	Kind   string         `json:"kind"`
+	Key    string         `json:"key"`   // 区分字段，通常是 type
+	Type   string         `json:"type"`  // 区分字段的取值，使用默认结构时为空
+	Types  []string       `json:"types"` // 区分字段所有可用的取值
+	Fields []*FieldSchema `json:"fields"`
+}
+
+// fieldSchemaHandler 处理 /api/field_schema 请求，返回多态对象某个类型的字段描述，供编辑器生成表单。
+// 参数 kind 为 schemaVariants 中的名称（outbound、inbound、endpoint、dns_server、route_rule 等），
+// type 为区分字段的取值；省略 type 时返回默认结构（如普通路由规则）。
+func fieldSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	kind := r.URL.Query().Get("kind")
+	typeName := r.URL.Query().Get("type")
+	set, ok := schemaVariants[kind]
+	if !ok {
+		writeJSONError(w, fmt.Sprintf("未知的结构 '%s'", kind), http.StatusNotFound)
+		return
+	}
+	sample := set.Default
+	if typeName != "" {
+		if sample, ok = set.Types[typeName]; !ok {
+			writeJSONError(w, fmt.Sprintf("未知的类型 %q，可选值: %s", typeName, strings.Join(variantTypeNames(set), ", ")), http.StatusNotFound)
+			return
+		}
+	} else if sample == nil {
+		writeJSONError(w, fmt.Sprintf("缺少 '%s' 参数", set.Key), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(FieldSchemaResponse{
+		Kind:   kind,
+		Key:    set.Key,
+		Type:   typeName,
+		Types:  variantTypeNames(set),
+		Fields: schemaOf(sample).Fields,
+	})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// schemaIssues 校验配置内容，返回 "路径: 信息" 形式的问题列表
+func schemaIssues(t *testing.T, content string) []string {
+	t.Helper()
+	var issues []string
+	for _, issue := range validateSchema("test.json", []byte(content)) {
+		issues = append(issues, issue.Path+": "+issue.Message)
+	}
+	return issues
+}
+
+func TestValidateSchemaRuleIPFields(t *testing.T) {
+	content := `{
+  "dns": {
+    "rules": [{"ip_cidr": ["10.0.0.0/8"], "ip_is_private": true, "server": "local"}]
+  },
+  "route": {
+    "rules": [
+      {"ip_cidr": ["10.0.0.0/8", "192.168.0.0/16"], "outbound": "direct"},
+      {"ip_is_private": true, "outbound": "direct"}
+    ]
+  }
+}`
+	if issues := schemaIssues(t, content); len(issues) > 0 {
+		t.Errorf("unexpected issues:\n%s", strings.Join(issues, "\n"))
+	}
+}
+
+func TestValidateSchemaClientConfig(t *testing.T) {
+	outbound := json.RawMessage(`{"type": "trojan", "tag": "x", "server": "t.example", "server_port": 443, "password": "pw", "tls": {"enabled": true}}`)
+	for _, template := range clientRuleTemplates {
+		for inbound := range clientInbounds {
+			config, err := buildClientConfig(outbound, inbound, template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, err := json.Marshal(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if issues := schemaIssues(t, string(content)); len(issues) > 0 {
+				t.Errorf("%s/%s: unexpected issues:\n%s", template.Key, inbound, strings.Join(issues, "\n"))
+			}
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"10s", 10 * time.Second, true},
+		{"1m30s", 90 * time.Second, true},
+		{"1d", 24 * time.Hour, true},
+		{"7d", 7 * 24 * time.Hour, true},
+		{"1d12h", 36 * time.Hour, true},
+		{"0.5d", 12 * time.Hour, true},
+		{"d", 0, false},
+		{"1dx", 0, false},
+		{"-1d", 0, false},
+		{"1x", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseDuration(tt.value)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseDuration(%q) = %v, %v; want %v, ok %v", tt.value, got, err, tt.want, tt.ok)
+		}
+	}
+
+	content := `{"route": {"rule_set": [{"type": "remote", "tag": "x", "format": "binary", "url": "https://example.com/x.srs", "update_interval": "1d"}]}}`
+	if issues := schemaIssues(t, content); len(issues) > 0 {
+		t.Errorf("unexpected issues:\n%s", strings.Join(issues, "\n"))
+	}
+}
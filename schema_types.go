@@ -0,0 +1,1128 @@
+package main
+
+import (
+	"encoding/json"
+)
+
+// 本文件用 Go 结构体描述 sing-box 配置（以 1.11/1.12 为准），既用于结构校验，也可以直接序列化生成配置片段。
+// 字段标签约定：
+//   json       与 sing-box 配置中的键名一致
+//   required   为 "true" 时必须提供
+//   enum       允许的取值，逗号分隔
+//   variants   多态对象（json.RawMessage）按 type 字段选择的结构集合，见 schemaVariants
+//   deprecated 为 "true" 时使用该字段会得到警告
+//   help       字段说明
+
+// Duration 形如 "10s"、"1m30s" 的时长字符串
+type Duration string
+
+// StringList 单个字符串或字符串数组
+type StringList []string
+
+// UnmarshalJSON 同时接受字符串和字符串数组
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = StringList{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// Uint16List 单个端口号或端口号数组
+type Uint16List []uint16
+
+// UnmarshalJSON 同时接受数字和数字数组
+func (l *Uint16List) UnmarshalJSON(data []byte) error {
+	var single uint16
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = Uint16List{single}
+		return nil
+	}
+	var list []uint16
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// SingboxConfig 配置文件的根对象，目录中的每个文件都是它的一部分
+type SingboxConfig struct {
+	Log          *LogOptions          `json:"log,omitempty" help:"日志"`
+	DNS          *DNSOptions          `json:"dns,omitempty" help:"DNS"`
+	NTP          *NTPOptions          `json:"ntp,omitempty" help:"NTP 时间同步"`
+	Certificate  *CertificateOptions  `json:"certificate,omitempty" help:"证书存储"`
+	Endpoints    []json.RawMessage    `json:"endpoints,omitempty" variants:"endpoint" help:"端点（WireGuard、Tailscale）"`
+	Inbounds     []json.RawMessage    `json:"inbounds,omitempty" variants:"inbound" help:"入站"`
+	Outbounds    []json.RawMessage    `json:"outbounds,omitempty" variants:"outbound" help:"出站"`
+	Route        *RouteOptions        `json:"route,omitempty" help:"路由"`
+	Services     []json.RawMessage    `json:"services,omitempty" help:"服务"`
+	Experimental *ExperimentalOptions `json:"experimental,omitempty" help:"实验性功能"`
+}
+
+// LogOptions ...
+type LogOptions struct {
+	Disabled  bool   `json:"disabled,omitempty" help:"禁用日志"`
+	Level     string `json:"level,omitempty" enum:"trace,debug,info,warn,error,fatal,panic" help:"日志等级"`
+	Output    string `json:"output,omitempty" help:"输出文件路径"`
+	Timestamp bool   `json:"timestamp,omitempty" help:"添加时间戳"`
+}
+
+// NTPOptions ...
+type NTPOptions struct {
+	Enabled    bool     `json:"enabled,omitempty"`
+	Server     string   `json:"server,omitempty" help:"NTP 服务器地址"`
+	ServerPort uint16   `json:"server_port,omitempty"`
+	Interval   Duration `json:"interval,omitempty" help:"同步间隔，默认 30m"`
+	DialFields
+}
+
+// CertificateOptions ...
+type CertificateOptions struct {
+	Store                    string     `json:"store,omitempty" enum:"system,mozilla,chrome,none"`
+	Certificate              StringList `json:"certificate,omitempty"`
+	CertificatePath          StringList `json:"certificate_path,omitempty"`
+	CertificateDirectoryPath StringList `json:"certificate_directory_path,omitempty"`
+}
+
+// ---------- 公共字段 ----------
+
+// DialFields 出站拨号字段
+type DialFields struct {
+	Detour              string          `json:"detour,omitempty" help:"通过指定出站建立连接"`
+	BindInterface       string          `json:"bind_interface,omitempty"`
+	Inet4BindAddress    string          `json:"inet4_bind_address,omitempty"`
+	Inet6BindAddress    string          `json:"inet6_bind_address,omitempty"`
+	RoutingMark         json.RawMessage `json:"routing_mark,omitempty"`
+	ReuseAddr           bool            `json:"reuse_addr,omitempty"`
+	Netns               string          `json:"netns,omitempty"`
+	ConnectTimeout      Duration        `json:"connect_timeout,omitempty"`
+	TCPFastOpen         bool            `json:"tcp_fast_open,omitempty"`
+	TCPMultiPath        bool            `json:"tcp_multi_path,omitempty"`
+	UDPFragment         bool            `json:"udp_fragment,omitempty"`
+	DomainResolver      json.RawMessage `json:"domain_resolver,omitempty" help:"解析服务器域名使用的 DNS 服务器"`
+	NetworkStrategy     string          `json:"network_strategy,omitempty" enum:"default,hybrid,fallback"`
+	NetworkType         StringList      `json:"network_type,omitempty"`
+	FallbackNetworkType StringList      `json:"fallback_network_type,omitempty"`
+	FallbackDelay       Duration        `json:"fallback_delay,omitempty"`
+	DomainStrategy      string          `json:"domain_strategy,omitempty" enum:"prefer_ipv4,prefer_ipv6,ipv4_only,ipv6_only" deprecated:"true"`
+}
+
+// ServerFields 出站服务器地址
+type ServerFields struct {
+	Server     string `json:"server" required:"true" help:"服务器地址"`
+	ServerPort uint16 `json:"server_port" required:"true" help:"服务器端口"`
+}
+
+// ListenFields 入站监听字段
+type ListenFields struct {
+	Listen        string          `json:"listen,omitempty" help:"监听地址，如 :: 或 0.0.0.0"`
+	ListenPort    uint16          `json:"listen_port,omitempty" help:"监听端口"`
+	BindInterface string          `json:"bind_interface,omitempty"`
+	RoutingMark   json.RawMessage `json:"routing_mark,omitempty"`
+	ReuseAddr     bool            `json:"reuse_addr,omitempty"`
+	Netns         string          `json:"netns,omitempty"`
+	TCPFastOpen   bool            `json:"tcp_fast_open,omitempty"`
+	TCPMultiPath  bool            `json:"tcp_multi_path,omitempty"`
+	UDPFragment   bool            `json:"udp_fragment,omitempty"`
+	UDPTimeout    Duration        `json:"udp_timeout,omitempty"`
+	Detour        string          `json:"detour,omitempty" help:"转发到指定入站"`
+	LegacyInboundFields
+}
+
+// LegacyInboundFields 1.11 起改由路由规则动作实现的旧入站字段
+type LegacyInboundFields struct {
+	Sniff                     bool     `json:"sniff,omitempty" deprecated:"true" help:"请改用路由规则动作 sniff"`
+	SniffOverrideDestination  bool     `json:"sniff_override_destination,omitempty" deprecated:"true"`
+	SniffTimeout              Duration `json:"sniff_timeout,omitempty" deprecated:"true"`
+	DomainStrategy            string   `json:"domain_strategy,omitempty" enum:"prefer_ipv4,prefer_ipv6,ipv4_only,ipv6_only" deprecated:"true"`
+	UDPDisableDomainUnmapping bool     `json:"udp_disable_domain_unmapping,omitempty" deprecated:"true"`
+}
+
+// ---------- TLS / 传输层 / 多路复用 ----------
+
+// InboundTLS 入站 TLS
+type InboundTLS struct {
+	Enabled         bool            `json:"enabled,omitempty"`
+	ServerName      string          `json:"server_name,omitempty"`
+	ALPN            StringList      `json:"alpn,omitempty"`
+	MinVersion      string          `json:"min_version,omitempty" enum:"1.0,1.1,1.2,1.3"`
+	MaxVersion      string          `json:"max_version,omitempty" enum:"1.0,1.1,1.2,1.3"`
+	CipherSuites    StringList      `json:"cipher_suites,omitempty"`
+	Certificate     StringList      `json:"certificate,omitempty"`
+	CertificatePath string          `json:"certificate_path,omitempty" help:"证书文件路径"`
+	Key             StringList      `json:"key,omitempty"`
+	KeyPath         string          `json:"key_path,omitempty" help:"私钥文件路径"`
+	ACME            json.RawMessage `json:"acme,omitempty"`
+	ECH             json.RawMessage `json:"ech,omitempty"`
+	Reality         *InboundReality `json:"reality,omitempty"`
+}
+
+// InboundReality 入站 Reality
+type InboundReality struct {
+	Enabled           bool             `json:"enabled,omitempty"`
+	Handshake         *HandshakeServer `json:"handshake,omitempty" help:"握手目标服务器"`
+	PrivateKey        string           `json:"private_key,omitempty" help:"由 sing-box generate reality-keypair 生成"`
+	ShortID           StringList       `json:"short_id,omitempty"`
+	MaxTimeDifference Duration         `json:"max_time_difference,omitempty"`
+}
+
+// HandshakeServer ShadowTLS / Reality 的握手服务器
+type HandshakeServer struct {
+	ServerFields
+	DialFields
+}
+
+// OutboundTLS 出站 TLS
+type OutboundTLS struct {
+	Enabled         bool             `json:"enabled,omitempty"`
+	DisableSNI      bool             `json:"disable_sni,omitempty"`
+	ServerName      string           `json:"server_name,omitempty"`
+	Insecure        bool             `json:"insecure,omitempty" help:"跳过证书验证"`
+	ALPN            StringList       `json:"alpn,omitempty"`
+	MinVersion      string           `json:"min_version,omitempty" enum:"1.0,1.1,1.2,1.3"`
+	MaxVersion      string           `json:"max_version,omitempty" enum:"1.0,1.1,1.2,1.3"`
+	CipherSuites    StringList       `json:"cipher_suites,omitempty"`
+	Certificate     StringList       `json:"certificate,omitempty"`
+	CertificatePath string           `json:"certificate_path,omitempty"`
+	Fragment        bool             `json:"fragment,omitempty"`
+	RecordFragment  bool             `json:"record_fragment,omitempty"`
+	ECH             json.RawMessage  `json:"ech,omitempty"`
+	UTLS            *OutboundUTLS    `json:"utls,omitempty"`
+	Reality         *OutboundReality `json:"reality,omitempty"`
+}
+
+// OutboundUTLS ...
+type OutboundUTLS struct {
+	Enabled     bool   `json:"enabled,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty" enum:"chrome,firefox,edge,safari,360,qq,ios,android,random,randomized"`
+}
+
+// OutboundReality ...
+type OutboundReality struct {
+	Enabled   bool   `json:"enabled,omitempty"`
+	PublicKey string `json:"public_key,omitempty"`
+	ShortID   string `json:"short_id,omitempty"`
+}
+
+// HTTPTransport ...
+type HTTPTransport struct {
+	Type        string                `json:"type" required:"true"`
+	Host        StringList            `json:"host,omitempty"`
+	Path        string                `json:"path,omitempty"`
+	Method      string                `json:"method,omitempty"`
+	Headers     map[string]StringList `json:"headers,omitempty"`
+	IdleTimeout Duration              `json:"idle_timeout,omitempty"`
+	PingTimeout Duration              `json:"ping_timeout,omitempty"`
+}
+
+// WebsocketTransport ...
+type WebsocketTransport struct {
+	Type                string                `json:"type" required:"true"`
+	Path                string                `json:"path,omitempty"`
+	Headers             map[string]StringList `json:"headers,omitempty"`
+	MaxEarlyData        uint32                `json:"max_early_data,omitempty"`
+	EarlyDataHeaderName string                `json:"early_data_header_name,omitempty"`
+}
+
+// QUICTransport ...
+type QUICTransport struct {
+	Type string `json:"type" required:"true"`
+}
+
+// GRPCTransport ...
+type GRPCTransport struct {
+	Type                string   `json:"type" required:"true"`
+	ServiceName         string   `json:"service_name,omitempty"`
+	IdleTimeout         Duration `json:"idle_timeout,omitempty"`
+	PingTimeout         Duration `json:"ping_timeout,omitempty"`
+	PermitWithoutStream bool     `json:"permit_without_stream,omitempty"`
+}
+
+// HTTPUpgradeTransport ...
+type HTTPUpgradeTransport struct {
+	Type    string                `json:"type" required:"true"`
+	Host    string                `json:"host,omitempty"`
+	Path    string                `json:"path,omitempty"`
+	Headers map[string]StringList `json:"headers,omitempty"`
+}
+
+// OutboundMultiplex ...
+type OutboundMultiplex struct {
+	Enabled        bool            `json:"enabled,omitempty"`
+	Protocol       string          `json:"protocol,omitempty" enum:"smux,yamux,h2mux"`
+	MaxConnections int             `json:"max_connections,omitempty"`
+	MinStreams     int             `json:"min_streams,omitempty"`
+	MaxStreams     int             `json:"max_streams,omitempty"`
+	Padding        bool            `json:"padding,omitempty"`
+	Brutal         json.RawMessage `json:"brutal,omitempty"`
+}
+
+// InboundMultiplex ...
+type InboundMultiplex struct {
+	Enabled bool            `json:"enabled,omitempty"`
+	Padding bool            `json:"padding,omitempty"`
+	Brutal  json.RawMessage `json:"brutal,omitempty"`
+}
+
+// ---------- 入站 ----------
+
+// InboundBase 所有入站共有的字段
+type InboundBase struct {
+	Type string `json:"type" required:"true" help:"入站类型"`
+	Tag  string `json:"tag,omitempty" help:"入站标签，路由规则通过它引用"`
+}
+
+// AuthUser 用户名密码认证
+type AuthUser struct {
+	Username string `json:"username" required:"true"`
+	Password string `json:"password" required:"true"`
+}
+
+// NamedPasswordUser ...
+type NamedPasswordUser struct {
+	Name     string `json:"name,omitempty"`
+	Password string `json:"password" required:"true"`
+}
+
+// DirectInbound ...
+type DirectInbound struct {
+	InboundBase
+	ListenFields
+	Network         string `json:"network,omitempty" enum:"tcp,udp"`
+	OverrideAddress string `json:"override_address,omitempty"`
+	OverridePort    uint16 `json:"override_port,omitempty"`
+}
+
+// MixedInbound 同时用于 mixed / socks
+type MixedInbound struct {
+	InboundBase
+	ListenFields
+	Users          []AuthUser `json:"users,omitempty"`
+	SetSystemProxy bool       `json:"set_system_proxy,omitempty"`
+}
+
+// HTTPInbound ...
+type HTTPInbound struct {
+	InboundBase
+	ListenFields
+	Users          []AuthUser  `json:"users,omitempty"`
+	SetSystemProxy bool        `json:"set_system_proxy,omitempty"`
+	TLS            *InboundTLS `json:"tls,omitempty"`
+}
+
+// ShadowsocksInbound ...
+type ShadowsocksInbound struct {
+	InboundBase
+	ListenFields
+	Network      string              `json:"network,omitempty" enum:"tcp,udp"`
+	Method       string              `json:"method" required:"true" enum:"2022-blake3-aes-128-gcm,2022-blake3-aes-256-gcm,2022-blake3-chacha20-poly1305,none,aes-128-gcm,aes-192-gcm,aes-256-gcm,chacha20-ietf-poly1305,xchacha20-ietf-poly1305,aes-128-ctr,aes-192-ctr,aes-256-ctr,aes-128-cfb,aes-192-cfb,aes-256-cfb,rc4-md5,chacha20-ietf,xchacha20"`
+	Password     string              `json:"password,omitempty"`
+	Users        []NamedPasswordUser `json:"users,omitempty"`
+	Destinations json.RawMessage     `json:"destinations,omitempty"`
+	Multiplex    *InboundMultiplex   `json:"multiplex,omitempty"`
+}
+
+// VMessUser ...
+type VMessUser struct {
+	Name    string `json:"name,omitempty"`
+	UUID    string `json:"uuid" required:"true"`
+	AlterID int    `json:"alterId,omitempty"`
+}
+
+// VMessInbound ...
+type VMessInbound struct {
+	InboundBase
+	ListenFields
+	Users     []VMessUser       `json:"users" required:"true"`
+	TLS       *InboundTLS       `json:"tls,omitempty"`
+	Multiplex *InboundMultiplex `json:"multiplex,omitempty"`
+	Transport json.RawMessage   `json:"transport,omitempty" variants:"transport"`
+}
+
+// TrojanInbound ...
+type TrojanInbound struct {
+	InboundBase
+	ListenFields
+	Users           []NamedPasswordUser `json:"users" required:"true"`
+	TLS             *InboundTLS         `json:"tls,omitempty"`
+	Fallback        json.RawMessage     `json:"fallback,omitempty"`
+	FallbackForALPN json.RawMessage     `json:"fallback_for_alpn,omitempty"`
+	Multiplex       *InboundMultiplex   `json:"multiplex,omitempty"`
+	Transport       json.RawMessage     `json:"transport,omitempty" variants:"transport"`
+}
+
+// NaiveInbound ...
+type NaiveInbound struct {
+	InboundBase
+	ListenFields
+	Users   []AuthUser  `json:"users" required:"true"`
+	Network string      `json:"network,omitempty" enum:"tcp,udp"`
+	TLS     *InboundTLS `json:"tls,omitempty"`
+}
+
+// HysteriaUser ...
+type HysteriaUser struct {
+	Name    string `json:"name,omitempty"`
+	Auth    string `json:"auth,omitempty"`
+	AuthStr string `json:"auth_str,omitempty"`
+}
+
+// HysteriaInbound ...
+type HysteriaInbound struct {
+	InboundBase
+	ListenFields
+	Up                  string         `json:"up,omitempty"`
+	UpMbps              int            `json:"up_mbps,omitempty"`
+	Down                string         `json:"down,omitempty"`
+	DownMbps            int            `json:"down_mbps,omitempty"`
+	Obfs                string         `json:"obfs,omitempty"`
+	Users               []HysteriaUser `json:"users,omitempty"`
+	RecvWindowConn      uint64         `json:"recv_window_conn,omitempty"`
+	RecvWindowClient    uint64         `json:"recv_window_client,omitempty"`
+	MaxConnClient       int            `json:"max_conn_client,omitempty"`
+	DisableMTUDiscovery bool           `json:"disable_mtu_discovery,omitempty"`
+	TLS                 *InboundTLS    `json:"tls" required:"true"`
+}
+
+// ShadowTLSInbound ...
+type ShadowTLSInbound struct {
+	InboundBase
+	ListenFields
+	Version                int                 `json:"version,omitempty" enum:"1,2,3"`
+	Password               string              `json:"password,omitempty"`
+	Users                  []NamedPasswordUser `json:"users,omitempty"`
+	Handshake              *HandshakeServer    `json:"handshake" required:"true"`
+	HandshakeForServerName json.RawMessage     `json:"handshake_for_server_name,omitempty"`
+	StrictMode             bool                `json:"strict_mode,omitempty"`
+	WildcardSNI            string              `json:"wildcard_sni,omitempty" enum:"off,authed,all"`
+}
+
+// TUICUser ...
+type TUICUser struct {
+	Name     string `json:"name,omitempty"`
+	UUID     string `json:"uuid" required:"true"`
+	Password string `json:"password,omitempty"`
+}
+
+// TUICInbound ...
+type TUICInbound struct {
+	InboundBase
+	ListenFields
+	Users             []TUICUser  `json:"users" required:"true"`
+	CongestionControl string      `json:"congestion_control,omitempty" enum:"cubic,new_reno,bbr"`
+	AuthTimeout       Duration    `json:"auth_timeout,omitempty"`
+	ZeroRTTHandshake  bool        `json:"zero_rtt_handshake,omitempty"`
+	Heartbeat         Duration    `json:"heartbeat,omitempty"`
+	TLS               *InboundTLS `json:"tls" required:"true"`
+}
+
+// Hysteria2Obfs ...
+type Hysteria2Obfs struct {
+	Type     string `json:"type,omitempty" enum:"salamander"`
+	Password string `json:"password,omitempty"`
+}
+
+// Hysteria2Inbound ...
+type Hysteria2Inbound struct {
+	InboundBase
+	ListenFields
+	UpMbps                int                 `json:"up_mbps,omitempty"`
+	DownMbps              int                 `json:"down_mbps,omitempty"`
+	Obfs                  *Hysteria2Obfs      `json:"obfs,omitempty"`
+	Users                 []NamedPasswordUser `json:"users" required:"true"`
+	IgnoreClientBandwidth bool                `json:"ignore_client_bandwidth,omitempty"`
+	TLS                   *InboundTLS         `json:"tls" required:"true"`
+	Masquerade            json.RawMessage     `json:"masquerade,omitempty"`
+	BrutalDebug           bool                `json:"brutal_debug,omitempty"`
+}
+
+// VLESSUser ...
+type VLESSUser struct {
+	Name string `json:"name,omitempty"`
+	UUID string `json:"uuid" required:"true"`
+	Flow string `json:"flow,omitempty" enum:"xtls-rprx-vision"`
+}
+
+// VLESSInbound ...
+type VLESSInbound struct {
+	InboundBase
+	ListenFields
+	Users     []VLESSUser       `json:"users" required:"true"`
+	TLS       *InboundTLS       `json:"tls,omitempty"`
+	Multiplex *InboundMultiplex `json:"multiplex,omitempty"`
+	Transport json.RawMessage   `json:"transport,omitempty" variants:"transport"`
+}
+
+// AnyTLSInbound ...
+type AnyTLSInbound struct {
+	InboundBase
+	ListenFields
+	Users         []NamedPasswordUser `json:"users" required:"true"`
+	PaddingScheme StringList          `json:"padding_scheme,omitempty"`
+	TLS           *InboundTLS         `json:"tls,omitempty"`
+}
+
+// TunInbound ...
+type TunInbound struct {
+	InboundBase
+	InterfaceName          string          `json:"interface_name,omitempty"`
+	MTU                    uint32          `json:"mtu,omitempty"`
+	Address                StringList      `json:"address,omitempty" help:"TUN 接口地址，如 172.18.0.1/30"`
+	AutoRoute              bool            `json:"auto_route,omitempty"`
+	IPRoute2TableIndex     int             `json:"iproute2_table_index,omitempty"`
+	IPRoute2RuleIndex      int             `json:"iproute2_rule_index,omitempty"`
+	AutoRedirect           bool            `json:"auto_redirect,omitempty"`
+	AutoRedirectInputMark  json.RawMessage `json:"auto_redirect_input_mark,omitempty"`
+	AutoRedirectOutputMark json.RawMessage `json:"auto_redirect_output_mark,omitempty"`
+	LoopbackAddress        StringList      `json:"loopback_address,omitempty"`
+	StrictRoute            bool            `json:"strict_route,omitempty"`
+	RouteAddress           StringList      `json:"route_address,omitempty"`
+	RouteExcludeAddress    StringList      `json:"route_exclude_address,omitempty"`
+	RouteAddressSet        StringList      `json:"route_address_set,omitempty"`
+	RouteExcludeAddressSet StringList      `json:"route_exclude_address_set,omitempty"`
+	EndpointIndependentNat bool            `json:"endpoint_independent_nat,omitempty"`
+	UDPTimeout             Duration        `json:"udp_timeout,omitempty"`
+	Stack                  string          `json:"stack,omitempty" enum:"system,gvisor,mixed"`
+	IncludeInterface       StringList      `json:"include_interface,omitempty"`
+	ExcludeInterface       StringList      `json:"exclude_interface,omitempty"`
+	IncludeUID             json.RawMessage `json:"include_uid,omitempty"`
+	IncludeUIDRange        StringList      `json:"include_uid_range,omitempty"`
+	ExcludeUID             json.RawMessage `json:"exclude_uid,omitempty"`
+	ExcludeUIDRange        StringList      `json:"exclude_uid_range,omitempty"`
+	IncludeAndroidUser     json.RawMessage `json:"include_android_user,omitempty"`
+	IncludePackage         StringList      `json:"include_package,omitempty"`
+	ExcludePackage         StringList      `json:"exclude_package,omitempty"`
+	Platform               json.RawMessage `json:"platform,omitempty"`
+	GSO                    bool            `json:"gso,omitempty" deprecated:"true"`
+	Inet4Address           StringList      `json:"inet4_address,omitempty" deprecated:"true" help:"请改用 address"`
+	Inet6Address           StringList      `json:"inet6_address,omitempty" deprecated:"true" help:"请改用 address"`
+	Inet4RouteAddress      StringList      `json:"inet4_route_address,omitempty" deprecated:"true"`
+	Inet6RouteAddress      StringList      `json:"inet6_route_address,omitempty" deprecated:"true"`
+	LegacyInboundFields
+}
+
+// RedirectInbound 同时用于 redirect / tproxy
+type RedirectInbound struct {
+	InboundBase
+	ListenFields
+	Network string `json:"network,omitempty" enum:"tcp,udp"`
+}
+
+// ---------- 出站 ----------
+
+// OutboundBase 所有出站共有的字段
+type OutboundBase struct {
+	Type string `json:"type" required:"true" help:"出站类型"`
+	Tag  string `json:"tag,omitempty" help:"出站标签，路由规则和选择器通过它引用"`
+}
+
+// DirectOutbound ...
+type DirectOutbound struct {
+	OutboundBase
+	DialFields
+	OverrideAddress string `json:"override_address,omitempty" deprecated:"true"`
+	OverridePort    uint16 `json:"override_port,omitempty" deprecated:"true"`
+	ProxyProtocol   int    `json:"proxy_protocol,omitempty" deprecated:"true"`
+}
+
+// BlockOutbound 同时用于已弃用的 block / dns 出站
+type BlockOutbound struct {
+	OutboundBase
+}
+
+// SOCKSOutbound ...
+type SOCKSOutbound struct {
+	OutboundBase
+	ServerFields
+	Version    string          `json:"version,omitempty" enum:"4,4a,5"`
+	Username   string          `json:"username,omitempty"`
+	Password   string          `json:"password,omitempty"`
+	Network    string          `json:"network,omitempty" enum:"tcp,udp"`
+	UDPOverTCP json.RawMessage `json:"udp_over_tcp,omitempty"`
+	DialFields
+}
+
+// HTTPOutbound ...
+type HTTPOutbound struct {
+	OutboundBase
+	ServerFields
+	Username string                `json:"username,omitempty"`
+	Password string                `json:"password,omitempty"`
+	Path     string                `json:"path,omitempty"`
+	Headers  map[string]StringList `json:"headers,omitempty"`
+	TLS      *OutboundTLS          `json:"tls,omitempty"`
+	DialFields
+}
+
+// ShadowsocksOutbound ...
+type ShadowsocksOutbound struct {
+	OutboundBase
+	ServerFields
+	Method     string             `json:"method" required:"true" enum:"2022-blake3-aes-128-gcm,2022-blake3-aes-256-gcm,2022-blake3-chacha20-poly1305,none,aes-128-gcm,aes-192-gcm,aes-256-gcm,chacha20-ietf-poly1305,xchacha20-ietf-poly1305,aes-128-ctr,aes-192-ctr,aes-256-ctr,aes-128-cfb,aes-192-cfb,aes-256-cfb,rc4-md5,chacha20-ietf,xchacha20"`
+	Password   string             `json:"password" required:"true"`
+	Plugin     string             `json:"plugin,omitempty" enum:"obfs-local,v2ray-plugin"`
+	PluginOpts string             `json:"plugin_opts,omitempty"`
+	Network    string             `json:"network,omitempty" enum:"tcp,udp"`
+	UDPOverTCP json.RawMessage    `json:"udp_over_tcp,omitempty"`
+	Multiplex  *OutboundMultiplex `json:"multiplex,omitempty"`
+	DialFields
+}
+
+// VMessOutbound ...
+type VMessOutbound struct {
+	OutboundBase
+	ServerFields
+	UUID                string             `json:"uuid" required:"true"`
+	Security            string             `json:"security,omitempty" enum:"auto,none,zero,aes-128-gcm,chacha20-poly1305,aes-128-ctr"`
+	AlterID             int                `json:"alter_id,omitempty"`
+	GlobalPadding       bool               `json:"global_padding,omitempty"`
+	AuthenticatedLength bool               `json:"authenticated_length,omitempty"`
+	Network             string             `json:"network,omitempty" enum:"tcp,udp"`
+	TLS                 *OutboundTLS       `json:"tls,omitempty"`
+	PacketEncoding      string             `json:"packet_encoding,omitempty" enum:"packetaddr,xudp"`
+	Transport           json.RawMessage    `json:"transport,omitempty" variants:"transport"`
+	Multiplex           *OutboundMultiplex `json:"multiplex,omitempty"`
+	DialFields
+}
+
+// TrojanOutbound ...
+type TrojanOutbound struct {
+	OutboundBase
+	ServerFields
+	Password  string             `json:"password" required:"true"`
+	Network   string             `json:"network,omitempty" enum:"tcp,udp"`
+	TLS       *OutboundTLS       `json:"tls,omitempty"`
+	Multiplex *OutboundMultiplex `json:"multiplex,omitempty"`
+	Transport json.RawMessage    `json:"transport,omitempty" variants:"transport"`
+	DialFields
+}
+
+// WireGuardOutbound 已弃用的 WireGuard 出站，1.11 起改为端点
+type WireGuardOutbound struct {
+	OutboundBase
+	ServerFields
+	SystemInterface bool            `json:"system_interface,omitempty"`
+	GSO             bool            `json:"gso,omitempty"`
+	InterfaceName   string          `json:"interface_name,omitempty"`
+	LocalAddress    StringList      `json:"local_address" required:"true"`
+	PrivateKey      string          `json:"private_key" required:"true"`
+	Peers           json.RawMessage `json:"peers,omitempty"`
+	PeerPublicKey   string          `json:"peer_public_key,omitempty"`
+	PreSharedKey    string          `json:"pre_shared_key,omitempty"`
+	Reserved        json.RawMessage `json:"reserved,omitempty"`
+	Workers         int             `json:"workers,omitempty"`
+	MTU             uint32          `json:"mtu,omitempty"`
+	Network         string          `json:"network,omitempty" enum:"tcp,udp"`
+	DialFields
+}
+
+// HysteriaOutbound ...
+type HysteriaOutbound struct {
+	OutboundBase
+	ServerFields
+	ServerPorts         StringList   `json:"server_ports,omitempty"`
+	HopInterval         Duration     `json:"hop_interval,omitempty"`
+	Up                  string       `json:"up,omitempty"`
+	UpMbps              int          `json:"up_mbps,omitempty"`
+	Down                string       `json:"down,omitempty"`
+	DownMbps            int          `json:"down_mbps,omitempty"`
+	Obfs                string       `json:"obfs,omitempty"`
+	Auth                string       `json:"auth,omitempty"`
+	AuthStr             string       `json:"auth_str,omitempty"`
+	RecvWindowConn      uint64       `json:"recv_window_conn,omitempty"`
+	RecvWindow          uint64       `json:"recv_window,omitempty"`
+	DisableMTUDiscovery bool         `json:"disable_mtu_discovery,omitempty"`
+	Network             string       `json:"network,omitempty" enum:"tcp,udp"`
+	TLS                 *OutboundTLS `json:"tls" required:"true"`
+	DialFields
+}
+
+// VLESSOutbound ...
+type VLESSOutbound struct {
+	OutboundBase
+	ServerFields
+	UUID           string             `json:"uuid" required:"true"`
+	Flow           string             `json:"flow,omitempty" enum:"xtls-rprx-vision"`
+	Network        string             `json:"network,omitempty" enum:"tcp,udp"`
+	TLS            *OutboundTLS       `json:"tls,omitempty"`
+	PacketEncoding string             `json:"packet_encoding,omitempty" enum:"packetaddr,xudp"`
+	Multiplex      *OutboundMultiplex `json:"multiplex,omitempty"`
+	Transport      json.RawMessage    `json:"transport,omitempty" variants:"transport"`
+	DialFields
+}
+
+// ShadowTLSOutbound ...
+type ShadowTLSOutbound struct {
+	OutboundBase
+	ServerFields
+	Version  int          `json:"version,omitempty" enum:"1,2,3"`
+	Password string       `json:"password,omitempty"`
+	TLS      *OutboundTLS `json:"tls" required:"true"`
+	DialFields
+}
+
+// TUICOutbound ...
+type TUICOutbound struct {
+	OutboundBase
+	ServerFields
+	UUID              string       `json:"uuid" required:"true"`
+	Password          string       `json:"password,omitempty"`
+	CongestionControl string       `json:"congestion_control,omitempty" enum:"cubic,new_reno,bbr"`
+	UDPRelayMode      string       `json:"udp_relay_mode,omitempty" enum:"native,quic"`
+	UDPOverStream     bool         `json:"udp_over_stream,omitempty"`
+	ZeroRTTHandshake  bool         `json:"zero_rtt_handshake,omitempty"`
+	Heartbeat         Duration     `json:"heartbeat,omitempty"`
+	Network           string       `json:"network,omitempty" enum:"tcp,udp"`
+	TLS               *OutboundTLS `json:"tls" required:"true"`
+	DialFields
+}
+
+// Hysteria2Outbound ...
+type Hysteria2Outbound struct {
+	OutboundBase
+	ServerFields
+	ServerPorts StringList     `json:"server_ports,omitempty" help:"端口跳跃范围，如 20000:30000"`
+	HopInterval Duration       `json:"hop_interval,omitempty"`
+	UpMbps      int            `json:"up_mbps,omitempty"`
+	DownMbps    int            `json:"down_mbps,omitempty"`
+	Obfs        *Hysteria2Obfs `json:"obfs,omitempty"`
+	Password    string         `json:"password,omitempty"`
+	Network     string         `json:"network,omitempty" enum:"tcp,udp"`
+	TLS         *OutboundTLS   `json:"tls" required:"true"`
+	BrutalDebug bool           `json:"brutal_debug,omitempty"`
+	DialFields
+}
+
+// AnyTLSOutbound ...
+type AnyTLSOutbound struct {
+	OutboundBase
+	ServerFields
+	Password                 string       `json:"password" required:"true"`
+	IdleSessionCheckInterval Duration     `json:"idle_session_check_interval,omitempty"`
+	IdleSessionTimeout       Duration     `json:"idle_session_timeout,omitempty"`
+	MinIdleSession           int          `json:"min_idle_session,omitempty"`
+	TLS                      *OutboundTLS `json:"tls" required:"true"`
+	DialFields
+}
+
+// TorOutbound ...
+type TorOutbound struct {
+	OutboundBase
+	ExecutablePath string            `json:"executable_path,omitempty"`
+	ExtraArgs      []string          `json:"extra_args,omitempty"`
+	DataDirectory  string            `json:"data_directory,omitempty"`
+	Torrc          map[string]string `json:"torrc,omitempty"`
+	DialFields
+}
+
+// SSHOutbound ...
+type SSHOutbound struct {
+	OutboundBase
+	ServerFields
+	User                 string     `json:"user,omitempty"`
+	Password             string     `json:"password,omitempty"`
+	PrivateKey           StringList `json:"private_key,omitempty"`
+	PrivateKeyPath       string     `json:"private_key_path,omitempty"`
+	PrivateKeyPassphrase string     `json:"private_key_passphrase,omitempty"`
+	HostKey              StringList `json:"host_key,omitempty"`
+	HostKeyAlgorithms    StringList `json:"host_key_algorithms,omitempty"`
+	ClientVersion        string     `json:"client_version,omitempty"`
+	DialFields
+}
+
+// SelectorOutbound ...
+type SelectorOutbound struct {
+	OutboundBase
+	Outbounds                 StringList `json:"outbounds" required:"true" help:"可供选择的出站标签"`
+	Default                   string     `json:"default,omitempty" help:"默认选中的出站标签"`
+	InterruptExistConnections bool       `json:"interrupt_exist_connections,omitempty"`
+}
+
+// URLTestOutbound ...
+type URLTestOutbound struct {
+	OutboundBase
+	Outbounds                 StringList `json:"outbounds" required:"true" help:"参与测速的出站标签"`
+	URL                       string     `json:"url,omitempty"`
+	Interval                  Duration   `json:"interval,omitempty"`
+	Tolerance                 uint16     `json:"tolerance,omitempty"`
+	IdleTimeout               Duration   `json:"idle_timeout,omitempty"`
+	InterruptExistConnections bool       `json:"interrupt_exist_connections,omitempty"`
+}
+
+// ---------- 端点 ----------
+
+// WireGuardPeer ...
+type WireGuardPeer struct {
+	Address                     string          `json:"address,omitempty"`
+	Port                        uint16          `json:"port,omitempty"`
+	PublicKey                   string          `json:"public_key" required:"true"`
+	PreSharedKey                string          `json:"pre_shared_key,omitempty"`
+	AllowedIPs                  StringList      `json:"allowed_ips,omitempty"`
+	PersistentKeepaliveInterval uint16          `json:"persistent_keepalive_interval,omitempty"`
+	Reserved                    json.RawMessage `json:"reserved,omitempty"`
+}
+
+// WireGuardEndpoint ...
+type WireGuardEndpoint struct {
+	OutboundBase
+	System     bool            `json:"system,omitempty"`
+	Name       string          `json:"name,omitempty"`
+	MTU        uint32          `json:"mtu,omitempty"`
+	Address    StringList      `json:"address" required:"true"`
+	PrivateKey string          `json:"private_key" required:"true"`
+	ListenPort uint16          `json:"listen_port,omitempty"`
+	Peers      []WireGuardPeer `json:"peers,omitempty"`
+	UDPTimeout Duration        `json:"udp_timeout,omitempty"`
+	Workers    int             `json:"workers,omitempty"`
+	DialFields
+}
+
+// TailscaleEndpoint ...
+type TailscaleEndpoint struct {
+	OutboundBase
+	StateDirectory         string     `json:"state_directory,omitempty"`
+	AuthKey                string     `json:"auth_key,omitempty"`
+	ControlURL             string     `json:"control_url,omitempty"`
+	Ephemeral              bool       `json:"ephemeral,omitempty"`
+	Hostname               string     `json:"hostname,omitempty"`
+	AcceptRoutes           bool       `json:"accept_routes,omitempty"`
+	ExitNode               string     `json:"exit_node,omitempty"`
+	ExitNodeAllowLANAccess bool       `json:"exit_node_allow_lan_access,omitempty"`
+	AdvertiseRoutes        StringList `json:"advertise_routes,omitempty"`
+	AdvertiseExitNode      bool       `json:"advertise_exit_node,omitempty"`
+	UDPTimeout             Duration   `json:"udp_timeout,omitempty"`
+	DialFields
+}
+
+// ---------- DNS ----------
+
+// DNSOptions ...
+type DNSOptions struct {
+	Servers          []json.RawMessage `json:"servers,omitempty" variants:"dns_server" help:"DNS 服务器"`
+	Rules            []json.RawMessage `json:"rules,omitempty" variants:"dns_rule" help:"DNS 规则"`
+	Final            string            `json:"final,omitempty" help:"默认 DNS 服务器标签"`
+	Strategy         string            `json:"strategy,omitempty" enum:"prefer_ipv4,prefer_ipv6,ipv4_only,ipv6_only"`
+	DisableCache     bool              `json:"disable_cache,omitempty"`
+	DisableExpire    bool              `json:"disable_expire,omitempty"`
+	IndependentCache bool              `json:"independent_cache,omitempty"`
+	CacheCapacity    uint32            `json:"cache_capacity,omitempty"`
+	ReverseMapping   bool              `json:"reverse_mapping,omitempty"`
+	ClientSubnet     string            `json:"client_subnet,omitempty"`
+	FakeIP           *LegacyFakeIP     `json:"fakeip,omitempty" deprecated:"true" help:"请改用 fakeip 类型的 DNS 服务器"`
+}
+
+// LegacyFakeIP ...
+type LegacyFakeIP struct {
+	Enabled    bool   `json:"enabled,omitempty"`
+	Inet4Range string `json:"inet4_range,omitempty"`
+	Inet6Range string `json:"inet6_range,omitempty"`
+}
+
+// LegacyDNSServer 1.12 之前以 address 描述的 DNS 服务器
+type LegacyDNSServer struct {
+	Tag             string `json:"tag,omitempty"`
+	Address         string `json:"address" required:"true" help:"如 tls://8.8.8.8、https://1.1.1.1/dns-query、local"`
+	AddressResolver string `json:"address_resolver,omitempty"`
+	AddressStrategy string `json:"address_strategy,omitempty" enum:"prefer_ipv4,prefer_ipv6,ipv4_only,ipv6_only"`
+	Strategy        string `json:"strategy,omitempty" enum:"prefer_ipv4,prefer_ipv6,ipv4_only,ipv6_only"`
+	Detour          string `json:"detour,omitempty"`
+	ClientSubnet    string `json:"client_subnet,omitempty"`
+}
+
+// DNSServerBase 1.12 起带 type 的 DNS 服务器共有字段
+type DNSServerBase struct {
+	Type string `json:"type" required:"true"`
+	Tag  string `json:"tag,omitempty"`
+}
+
+// LocalDNSServer ...
+type LocalDNSServer struct {
+	DNSServerBase
+	PreferGo bool `json:"prefer_go,omitempty"`
+	DialFields
+}
+
+// HostsDNSServer ...
+type HostsDNSServer struct {
+	DNSServerBase
+	Path       StringList            `json:"path,omitempty"`
+	Predefined map[string]StringList `json:"predefined,omitempty"`
+}
+
+// RemoteDNSServer 用于 tcp / udp
+type RemoteDNSServer struct {
+	DNSServerBase
+	Server     string `json:"server" required:"true"`
+	ServerPort uint16 `json:"server_port,omitempty"`
+	DialFields
+}
+
+// TLSDNSServer 用于 tls / quic
+type TLSDNSServer struct {
+	DNSServerBase
+	Server     string       `json:"server" required:"true"`
+	ServerPort uint16       `json:"server_port,omitempty"`
+	TLS        *OutboundTLS `json:"tls,omitempty"`
+	DialFields
+}
+
+// HTTPSDNSServer 用于 https / h3
+type HTTPSDNSServer struct {
+	DNSServerBase
+	Server     string                `json:"server" required:"true"`
+	ServerPort uint16                `json:"server_port,omitempty"`
+	Path       string                `json:"path,omitempty"`
+	Headers    map[string]StringList `json:"headers,omitempty"`
+	TLS        *OutboundTLS          `json:"tls,omitempty"`
+	DialFields
+}
+
+// DHCPDNSServer ...
+type DHCPDNSServer struct {
+	DNSServerBase
+	Interface string `json:"interface,omitempty"`
+	DialFields
+}
+
+// FakeIPDNSServer ...
+type FakeIPDNSServer struct {
+	DNSServerBase
+	Inet4Range string `json:"inet4_range,omitempty"`
+	Inet6Range string `json:"inet6_range,omitempty"`
+}
+
+// TailscaleDNSServer ...
+type TailscaleDNSServer struct {
+	DNSServerBase
+	Endpoint               string `json:"endpoint" required:"true"`
+	AcceptDefaultResolvers bool   `json:"accept_default_resolvers,omitempty"`
+}
+
+// ResolvedDNSServer ...
+type ResolvedDNSServer struct {
+	DNSServerBase
+	Service                string `json:"service" required:"true"`
+	AcceptDefaultResolvers bool   `json:"accept_default_resolvers,omitempty"`
+}
+
+// ---------- 规则 ----------
+
+// RuleMatchFields 路由规则和 DNS 规则共有的匹配条件
+type RuleMatchFields struct {
+	Inbound                  StringList      `json:"inbound,omitempty" help:"入站标签"`
+	IPVersion                int             `json:"ip_version,omitempty" enum:"4,6"`
+	Network                  StringList      `json:"network,omitempty"`
+	AuthUser                 StringList      `json:"auth_user,omitempty"`
+	Protocol                 StringList      `json:"protocol,omitempty"`
+	Client                   StringList      `json:"client,omitempty"`
+	Domain                   StringList      `json:"domain,omitempty"`
+	DomainSuffix             StringList      `json:"domain_suffix,omitempty"`
+	DomainKeyword            StringList      `json:"domain_keyword,omitempty"`
+	DomainRegex              StringList      `json:"domain_regex,omitempty"`
+	Geosite                  StringList      `json:"geosite,omitempty" deprecated:"true" help:"请改用规则集"`
+	SourceGeoIP              StringList      `json:"source_geoip,omitempty" deprecated:"true"`
+	GeoIP                    StringList      `json:"geoip,omitempty" deprecated:"true" help:"请改用规则集"`
+	IPCIDR                   StringList      `json:"ip_cidr,omitempty"`
+	IPIsPrivate              bool            `json:"ip_is_private,omitempty"`
+	SourceIPCIDR             StringList      `json:"source_ip_cidr,omitempty"`
+	SourceIPIsPrivate        bool            `json:"source_ip_is_private,omitempty"`
+	SourcePort               Uint16List      `json:"source_port,omitempty"`
+	SourcePortRange          StringList      `json:"source_port_range,omitempty"`
+	Port                     Uint16List      `json:"port,omitempty"`
+	PortRange                StringList      `json:"port_range,omitempty"`
+	ProcessName              StringList      `json:"process_name,omitempty"`
+	ProcessPath              StringList      `json:"process_path,omitempty"`
+	ProcessPathRegex         StringList      `json:"process_path_regex,omitempty"`
+	PackageName              StringList      `json:"package_name,omitempty"`
+	User                     StringList      `json:"user,omitempty"`
+	UserID                   json.RawMessage `json:"user_id,omitempty"`
+	ClashMode                string          `json:"clash_mode,omitempty"`
+	NetworkType              StringList      `json:"network_type,omitempty"`
+	NetworkIsExpensive       bool            `json:"network_is_expensive,omitempty"`
+	NetworkIsConstrained     bool            `json:"network_is_constrained,omitempty"`
+	WIFISSID                 StringList      `json:"wifi_ssid,omitempty"`
+	WIFIBSSID                StringList      `json:"wifi_bssid,omitempty"`
+	RuleSet                  StringList      `json:"rule_set,omitempty" help:"规则集标签"`
+	RuleSetIPCIDRMatchSource bool            `json:"rule_set_ip_cidr_match_source,omitempty"`
+	RuleSetIPCIDRAcceptEmpty bool            `json:"rule_set_ip_cidr_accept_empty,omitempty"`
+	Invert                   bool            `json:"invert,omitempty"`
+}
+
+// RouteActionFields 路由规则动作
+type RouteActionFields struct {
+	Action                    string     `json:"action,omitempty" enum:"route,route-options,reject,hijack-dns,sniff,resolve,bypass" help:"规则动作，默认 route"`
+	Outbound                  string     `json:"outbound,omitempty" help:"目标出站标签"`
+	OverrideAddress           string     `json:"override_address,omitempty"`
+	OverridePort              uint16     `json:"override_port,omitempty"`
+	NetworkStrategy           string     `json:"network_strategy,omitempty" enum:"default,hybrid,fallback"`
+	FallbackDelay             Duration   `json:"fallback_delay,omitempty"`
+	UDPDisableDomainUnmapping bool       `json:"udp_disable_domain_unmapping,omitempty"`
+	UDPConnect                bool       `json:"udp_connect,omitempty"`
+	UDPTimeout                Duration   `json:"udp_timeout,omitempty"`
+	TLSFragment               bool       `json:"tls_fragment,omitempty"`
+	TLSFragmentFallbackDelay  Duration   `json:"tls_fragment_fallback_delay,omitempty"`
+	TLSRecordFragment         bool       `json:"tls_record_fragment,omitempty"`
+	Method                    string     `json:"method,omitempty" enum:"default,drop"`
+	NoDrop                    bool       `json:"no_drop,omitempty"`
+	Sniffer                   StringList `json:"sniffer,omitempty"`
+	Timeout                   Duration   `json:"timeout,omitempty"`
+	Strategy                  string     `json:"strategy,omitempty" enum:"prefer_ipv4,prefer_ipv6,ipv4_only,ipv6_only"`
+	Server                    string     `json:"server,omitempty"`
+}
+
+// DefaultRouteRule 普通路由规则
+type DefaultRouteRule struct {
+	Type string `json:"type,omitempty" enum:"default"`
+	RuleMatchFields
+	RouteActionFields
+}
+
+// LogicalRouteRule 组合多条路由规则
+type LogicalRouteRule struct {
+	Type   string            `json:"type" required:"true"`
+	Mode   string            `json:"mode" required:"true" enum:"and,or"`
+	Rules  []json.RawMessage `json:"rules" required:"true" variants:"route_rule"`
+	Invert bool              `json:"invert,omitempty"`
+	RouteActionFields
+}
+
+// DNSActionFields DNS 规则动作
+type DNSActionFields struct {
+	Action       string          `json:"action,omitempty" enum:"route,route-options,reject,predefined" help:"规则动作，默认 route"`
+	Server       string          `json:"server,omitempty" help:"目标 DNS 服务器标签"`
+	Strategy     string          `json:"strategy,omitempty" enum:"prefer_ipv4,prefer_ipv6,ipv4_only,ipv6_only"`
+	DisableCache bool            `json:"disable_cache,omitempty"`
+	RewriteTTL   json.RawMessage `json:"rewrite_ttl,omitempty"`
+	ClientSubnet string          `json:"client_subnet,omitempty"`
+	Method       string          `json:"method,omitempty" enum:"default,drop"`
+	NoDrop       bool            `json:"no_drop,omitempty"`
+	Rcode        string          `json:"rcode,omitempty"`
+	Answer       StringList      `json:"answer,omitempty"`
+	Ns           StringList      `json:"ns,omitempty"`
+	Extra        StringList      `json:"extra,omitempty"`
+}
+
+// DefaultDNSRule 普通 DNS 规则
+type DefaultDNSRule struct {
+	Type string `json:"type,omitempty" enum:"default"`
+	RuleMatchFields
+	QueryType   json.RawMessage `json:"query_type,omitempty"`
+	Outbound    StringList      `json:"outbound,omitempty" deprecated:"true" help:"按出站匹配"`
+	IPAcceptAny bool            `json:"ip_accept_any,omitempty"`
+	DNSActionFields
+}
+
+// LogicalDNSRule 组合多条 DNS 规则
+type LogicalDNSRule struct {
+	Type   string            `json:"type" required:"true"`
+	Mode   string            `json:"mode" required:"true" enum:"and,or"`
+	Rules  []json.RawMessage `json:"rules" required:"true" variants:"dns_rule"`
+	Invert bool              `json:"invert,omitempty"`
+	DNSActionFields
+}
+
+// ---------- 路由 ----------
+
+// RouteOptions ...
+type RouteOptions struct {
+	Rules                      []json.RawMessage `json:"rules,omitempty" variants:"route_rule" help:"路由规则，按顺序匹配"`
+	RuleSet                    []json.RawMessage `json:"rule_set,omitempty" variants:"rule_set" help:"规则集"`
+	Final                      string            `json:"final,omitempty" help:"默认出站标签"`
+	AutoDetectInterface        bool              `json:"auto_detect_interface,omitempty"`
+	OverrideAndroidVPN         bool              `json:"override_android_vpn,omitempty"`
+	DefaultInterface           string            `json:"default_interface,omitempty"`
+	DefaultMark                json.RawMessage   `json:"default_mark,omitempty"`
+	DefaultDomainResolver      json.RawMessage   `json:"default_domain_resolver,omitempty"`
+	DefaultNetworkStrategy     string            `json:"default_network_strategy,omitempty" enum:"default,hybrid,fallback"`
+	DefaultNetworkType         StringList        `json:"default_network_type,omitempty"`
+	DefaultFallbackNetworkType StringList        `json:"default_fallback_network_type,omitempty"`
+	DefaultFallbackDelay       Duration          `json:"default_fallback_delay,omitempty"`
+	FindProcess                bool              `json:"find_process,omitempty"`
+	GeoIP                      json.RawMessage   `json:"geoip,omitempty" deprecated:"true"`
+	Geosite                    json.RawMessage   `json:"geosite,omitempty" deprecated:"true"`
+}
+
+// RuleSetBase 规则集共有字段
+type RuleSetBase struct {
+	Type string `json:"type" required:"true"`
+	Tag  string `json:"tag" required:"true" help:"规则集标签，规则通过 rule_set 引用"`
+}
+
+// InlineRuleSet ...
+type InlineRuleSet struct {
+	RuleSetBase
+	Rules []json.RawMessage `json:"rules" required:"true"`
+}
+
+// LocalRuleSet ...
+type LocalRuleSet struct {
+	RuleSetBase
+	Format string `json:"format,omitempty" enum:"source,binary"`
+	Path   string `json:"path" required:"true"`
+}
+
+// RemoteRuleSet ...
+type RemoteRuleSet struct {
+	RuleSetBase
+	Format         string   `json:"format,omitempty" enum:"source,binary"`
+	URL            string   `json:"url" required:"true"`
+	DownloadDetour string   `json:"download_detour,omitempty" help:"下载规则集使用的出站标签"`
+	UpdateInterval Duration `json:"update_interval,omitempty"`
+}
+
+// ---------- 实验性 ----------
+
+// ExperimentalOptions ...
+type ExperimentalOptions struct {
+	CacheFile *CacheFileOptions `json:"cache_file,omitempty"`
+	ClashAPI  *ClashAPIOptions  `json:"clash_api,omitempty"`
+	V2RayAPI  *V2RayAPIOptions  `json:"v2ray_api,omitempty"`
+}
+
+// CacheFileOptions ...
+type CacheFileOptions struct {
+	Enabled     bool     `json:"enabled,omitempty"`
+	Path        string   `json:"path,omitempty"`
+	CacheID     string   `json:"cache_id,omitempty"`
+	StoreFakeIP bool     `json:"store_fakeip,omitempty"`
+	StoreRDRC   bool     `json:"store_rdrc,omitempty"`
+	RDRCTimeout Duration `json:"rdrc_timeout,omitempty"`
+}
+
+// ClashAPIOptions ...
+type ClashAPIOptions struct {
+	ExternalController               string     `json:"external_controller,omitempty" help:"如 127.0.0.1:9090"`
+	ExternalUI                       string     `json:"external_ui,omitempty"`
+	ExternalUIDownloadURL            string     `json:"external_ui_download_url,omitempty"`
+	ExternalUIDownloadDetour         string     `json:"external_ui_download_detour,omitempty"`
+	Secret                           string     `json:"secret,omitempty"`
+	DefaultMode                      string     `json:"default_mode,omitempty"`
+	AccessControlAllowOrigin         StringList `json:"access_control_allow_origin,omitempty"`
+	AccessControlAllowPrivateNetwork bool       `json:"access_control_allow_private_network,omitempty"`
+	StoreMode                        bool       `json:"store_mode,omitempty" deprecated:"true"`
+	StoreSelected                    bool       `json:"store_selected,omitempty" deprecated:"true"`
+	StoreFakeIP                      bool       `json:"store_fakeip,omitempty" deprecated:"true"`
+	CacheFile                        string     `json:"cache_file,omitempty" deprecated:"true"`
+	CacheID                          string     `json:"cache_id,omitempty" deprecated:"true"`
+}
+
+// V2RayAPIOptions ...
+type V2RayAPIOptions struct {
+	Listen string             `json:"listen,omitempty"`
+	Stats  *V2RayStatsOptions `json:"stats,omitempty"`
+}
+
+// V2RayStatsOptions ...
+type V2RayStatsOptions struct {
+	Enabled   bool       `json:"enabled,omitempty"`
+	Inbounds  StringList `json:"inbounds,omitempty"`
+	Outbounds StringList `json:"outbounds,omitempty"`
+	Users     StringList `json:"users,omitempty"`
+}
@@ -0,0 +1,607 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultSubscriptionInterval 订阅未设置更新间隔时使用的默认值
+	defaultSubscriptionInterval = 12 * time.Hour
+	// minSubscriptionInterval 允许的最短更新间隔，避免频繁请求订阅服务器
+	minSubscriptionInterval = 5 * time.Minute
+	// subscriptionBodyLimit 订阅内容的最大字节数
+	subscriptionBodyLimit = 16 << 20
+	// subscriptionRetryDelay 更新失败后第一次重试的等待时间，之后每次失败加倍，最长为更新间隔
+	subscriptionRetryDelay = time.Minute
+	// defaultSubscriptionUserAgent 默认的 User-Agent，多数机场据此返回 sing-box 格式
+	defaultSubscriptionUserAgent = "sing-box"
+)
+
+// SubscriptionConfig 一个远程订阅，保存在编辑器配置的 subscriptions 中。
+// 每次更新都会整体重写 File：其中是订阅的所有节点，以及一个选择器（标签为 Name）和一个自动测速组（Name-auto）。
+type SubscriptionConfig struct {
+	Name      string `json:"name"`                 // 唯一名称，同时是生成的选择器出站的标签
+	URL       string `json:"url"`                  // 订阅地址
+	Interval  string `json:"interval,omitempty"`   // 更新间隔，如 6h，默认 12h，最短 5m
+	TagPrefix string `json:"tag_prefix,omitempty"` // 加在节点标签前的前缀，如 "[机场A] "
+	Include   string `json:"include,omitempty"`    // 正则，只保留名称匹配的节点
+	Exclude   string `json:"exclude,omitempty"`    // 正则，去掉名称匹配的节点
+	File      string `json:"file"`                 // 生成的出站文件，必须位于配置目录根目录
+	UserAgent string `json:"user_agent,omitempty"` // 请求订阅时的 User-Agent，默认 sing-box
+	Disabled  bool   `json:"disabled,omitempty"`   // 停用后不再自动更新，仍可手动更新
+}
+
+// interval 返回订阅的更新间隔
+func (sub *SubscriptionConfig) interval() time.Duration {
+	if d, err := time.ParseDuration(sub.Interval); err == nil && d > 0 {
+		return d
+	}
+	return defaultSubscriptionInterval
+}
+
+// retryDelay 返回连续失败 failures 次后到下一次重试的等待时间
+func (sub *SubscriptionConfig) retryDelay(failures int) time.Duration {
+	delay := subscriptionRetryDelay
+	for i := 1; i < failures && delay < sub.interval(); i++ {
+		delay *= 2
+	}
+	return min(delay, sub.interval())
+}
+
+// validateSubscription 校验订阅设置，others 是其他订阅（用于检查名称和文件是否重复）
+func validateSubscription(sub *SubscriptionConfig, others []SubscriptionConfig) error {
+	sub.Name = strings.TrimSpace(sub.Name)
+	if sub.Name == "" {
+		return fmt.Errorf("订阅名称不能为空")
+	}
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("订阅地址必须是 http 或 https 链接")
+	}
+	if sub.Interval != "" {
+		d, err := time.ParseDuration(sub.Interval)
+		if err != nil {
+			return fmt.Errorf("无效的更新间隔 '%s'，应形如 30m、6h", sub.Interval)
+		}
+		if d < minSubscriptionInterval {
+			return fmt.Errorf("更新间隔不能短于 %v", minSubscriptionInterval)
+		}
+	}
+	for _, expr := range []string{sub.Include, sub.Exclude} {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("无效的正则表达式 '%s': %v", expr, err)
+		}
+	}
+	if err := checkRelativeConfigPath(sub.File); err != nil {
+		return err
+	}
+	if strings.Contains(sub.File, "/") || strings.HasPrefix(sub.File, ".") {
+		return fmt.Errorf("订阅文件必须位于配置目录根目录，sing-box 不会读取子目录")
+	}
+	for _, other := range others {
+		if other.Name == sub.Name {
+			return fmt.Errorf("已经存在名为 '%s' 的订阅", sub.Name)
+		}
+		if other.File == sub.File {
+			return fmt.Errorf("文件 '%s' 已被订阅 '%s' 使用", sub.File, other.Name)
+		}
+	}
+	return nil
+}
+
+// SubscriptionStatus 订阅最近一次更新的结果，只保存在内存中
+type SubscriptionStatus struct {
+	Running     bool       `json:"running"`
+	LastFetch   *time.Time `json:"last_fetch,omitempty"`   // 最近一次尝试更新的时间
+	LastSuccess *time.Time `json:"last_success,omitempty"` // 最近一次成功写入（或内容无变化）的时间
+	Failures    int        `json:"failures,omitempty"`     // 连续失败的次数，决定下一次自动重试的时间
+	Format      string     `json:"format,omitempty"`       // 识别出的订阅格式：links / sing-box / clash
+	Nodes       int        `json:"nodes"`                  // 写入的节点数
+	Skipped     []string   `json:"skipped,omitempty"`      // 无法解析的节点及原因
+	Message     string     `json:"message,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	CheckOutput string     `json:"check_output,omitempty"` // sing-box check 未通过时的输出
+}
+
+var (
+	subscriptionStatuses = make(map[string]*SubscriptionStatus)
+	subscriptionMutex    sync.Mutex
+)
+
+// subscriptionStatus 返回订阅状态的副本
+func subscriptionStatus(name string) SubscriptionStatus {
+	subscriptionMutex.Lock()
+	defer subscriptionMutex.Unlock()
+	if st, ok := subscriptionStatuses[name]; ok {
+		return *st
+	}
+	return SubscriptionStatus{}
+}
+
+// fetchSubscription 下载订阅内容
+func fetchSubscription(sub *SubscriptionConfig) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, sub.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	userAgent := sub.UserAgent
+	if userAgent == "" {
+		userAgent = defaultSubscriptionUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载订阅失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("订阅服务器返回 %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, subscriptionBodyLimit+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取订阅内容失败: %v", err)
+	}
+	if len(body) > subscriptionBodyLimit {
+		return nil, fmt.Errorf("订阅内容超过 %d MB", subscriptionBodyLimit>>20)
+	}
+	return body, nil
+}
+
+// subscriptionGroupTypes sing-box 订阅中不作为节点导入的出站类型
+var subscriptionGroupTypes = map[string]bool{
+	"selector": true, "urltest": true, "direct": true, "block": true, "dns": true,
+}
+
+// parseSubscriptionContent 识别并解析订阅内容：sing-box JSON、Clash YAML，或（base64 编码的）分享链接列表
+func parseSubscriptionContent(body []byte) ([]ImportedOutbound, string, error) {
+	trimmed := bytes.TrimSpace(body)
+	if bytes.HasPrefix(trimmed, []byte("{")) && validateJSONC(trimmed) == nil {
+		var items []ImportedOutbound
+		for _, outbound := range jsoncParse(trimmed).Get("outbounds").Array() {
+			typ := outbound.Get("type").String()
+			if subscriptionGroupTypes[typ] {
+				continue
+			}
+			tag := outbound.Get("tag").String()
+			item := ImportedOutbound{Link: tag, Tag: tag, Type: typ, Outbound: json.RawMessage(outbound.Raw)}
+			if tag == "" {
+				item.Error = "出站没有标签"
+			}
+			items = append(items, item)
+		}
+		return items, "sing-box", nil
+	}
+	var clash struct {
+		Proxies []yaml.Node `yaml:"proxies"`
+	}
+	if err := yaml.Unmarshal(trimmed, &clash); err == nil && len(clash.Proxies) > 0 {
+		return parseClashProxies(clash.Proxies), "clash", nil
+	}
+	links := splitShareLinks(string(trimmed))
+	var items []ImportedOutbound
+	for _, item := range parseShareLinkItems(links) {
+		// 订阅中常夹带说明文字，不是链接的行直接忽略
+		if strings.Contains(item.Link, "://") {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return nil, "", fmt.Errorf("无法识别订阅内容：不是 sing-box 配置、Clash 配置或分享链接列表")
+	}
+	return items, "links", nil
+}
+
+// filterSubscriptionItems 按 include / exclude 过滤节点并加上标签前缀，返回保留的节点和跳过的原因
+func filterSubscriptionItems(sub *SubscriptionConfig, items []ImportedOutbound) ([]ImportedOutbound, []string) {
+	include := regexp.MustCompile(sub.Include)
+	exclude := regexp.MustCompile(sub.Exclude)
+	var kept []ImportedOutbound
+	var skipped []string
+	for _, item := range items {
+		switch {
+		case item.Error != "":
+			skipped = append(skipped, fmt.Sprintf("%s: %s", item.Link, item.Error))
+			continue
+		case sub.Include != "" && !include.MatchString(item.Tag):
+			continue
+		case sub.Exclude != "" && exclude.MatchString(item.Tag):
+			continue
+		}
+		if sub.TagPrefix != "" {
+			raw, err := jsoncSetRaw(item.Outbound, "tag", jsonQuote(sub.TagPrefix+item.Tag))
+			if err != nil {
+				skipped = append(skipped, fmt.Sprintf("%s: %v", item.Link, err))
+				continue
+			}
+			item.Outbound, item.Tag = raw, sub.TagPrefix+item.Tag
+		}
+		kept = append(kept, item)
+	}
+	return kept, skipped
+}
+
+// buildSubscriptionFile 生成订阅文件的内容：选择器、自动测速组和所有节点
+func buildSubscriptionFile(sub *SubscriptionConfig, items []ImportedOutbound) ([]byte, error) {
+	autoTag := sub.Name + "-auto"
+	var tags []string
+	for _, item := range items {
+		tags = append(tags, item.Tag)
+	}
+	selector, err := json.Marshal(SelectorOutbound{
+		OutboundBase: OutboundBase{Type: "selector", Tag: sub.Name},
+		Outbounds:    append([]string{autoTag}, tags...),
+		Default:      autoTag,
+	})
+	if err != nil {
+		return nil, err
+	}
+	urltest, err := json.Marshal(URLTestOutbound{
+		OutboundBase: OutboundBase{Type: "urltest", Tag: autoTag},
+		Outbounds:    tags,
+	})
+	if err != nil {
+		return nil, err
+	}
+	outbounds := []json.RawMessage{selector, urltest}
+	for _, item := range items {
+		outbounds = append(outbounds, item.Outbound)
+	}
+	content, err := json.Marshal(map[string]interface{}{"outbounds": outbounds})
+	if err != nil {
+		return nil, err
+	}
+	header := fmt.Sprintf("// 由订阅 %s 自动生成，每次更新都会整体覆盖，请不要手动修改\n", jsonQuote(sub.Name))
+	return append([]byte(header), formatJSONC(content, formatIndentString(getEditorConfig().Format.Indent))...), nil
+}
+
+// subscriptionFilePath 返回订阅文件的完整路径，文件可以尚不存在
+func subscriptionFilePath(filename string) (string, bool, error) {
+	if filePath, err := validateFilename(filename); err == nil {
+		return filePath, true, nil
+	}
+	filePath, err := validateNewFilename(filename)
+	if errors.Is(err, errFileExists) {
+		return "", false, fmt.Errorf("文件 '%s' 无法写入", filename)
+	}
+	return filePath, false, err
+}
+
+// updateSubscription 下载并解析订阅，通过 sing-box check 后重写订阅文件。username 用于版本历史，自动更新时为空。
+func updateSubscription(sub SubscriptionConfig, username string) (SubscriptionStatus, error) {
+	var st SubscriptionStatus
+	body, err := fetchSubscription(&sub)
+	if err != nil {
+		return st, err
+	}
+	items, format, err := parseSubscriptionContent(body)
+	if err != nil {
+		return st, err
+	}
+	st.Format = format
+	items, st.Skipped = filterSubscriptionItems(&sub, items)
+
+	configWriteMutex.Lock()
+	defer configWriteMutex.Unlock()
+
+	// 标签只需要与其他文件中的出站不冲突，订阅文件本身会被整体覆盖
+	taken := make(map[string]bool)
+	if index, err := buildTagIndex(); err == nil {
+		for tag, defs := range index.definitions(tagKindOutbound) {
+			for _, def := range defs {
+				if def.File != sub.File {
+					taken[tag] = true
+				}
+			}
+		}
+	}
+	for _, group := range []string{sub.Name, sub.Name + "-auto"} {
+		if taken[group] {
+			return st, fmt.Errorf("标签 '%s' 已被其他文件中的出站使用，请修改订阅名称", group)
+		}
+		taken[group] = true
+	}
+	items = dedupeImportedTags(items, func(tag string) bool { return taken[tag] }, true)
+	st.Nodes = len(items)
+	if st.Nodes == 0 {
+		return st, fmt.Errorf("过滤后没有可用的节点，订阅文件保持不变")
+	}
+	content, err := buildSubscriptionFile(&sub, items)
+	if err != nil {
+		return st, err
+	}
+
+	filePath, exists, err := subscriptionFilePath(sub.File)
+	if err != nil {
+		return st, err
+	}
+	if exists {
+		if current, err := os.ReadFile(filePath); err == nil && bytes.Equal(current, content) {
+			st.Message = fmt.Sprintf("%d 个节点，内容没有变化", st.Nodes)
+			return st, nil
+		}
+	}
+	output, err := checkStagedConfig(map[string][]byte{sub.File: content})
+	if errors.Is(err, errSingboxNotFound) {
+		st.Message = "未找到 sing-box，已跳过配置检查。"
+	} else if err != nil {
+		st.CheckOutput = output
+		return st, fmt.Errorf("配置检查未通过，订阅文件保持不变: %v", err)
+	}
+	if exists {
+		if _, err := createBackup(sub.File, filePath); err != nil {
+			return st, err
+		}
+	} else if err := ensureParentDir(filePath); err != nil {
+		return st, err
+	}
+	if err := writeConfigFile(filePath, content); err != nil {
+		return st, fmt.Errorf("写入订阅文件失败: %v", err)
+	}
+	rememberVersion(content)
+	recordHistory(sub.File, username, fmt.Sprintf("更新订阅 %s，%d 个节点", sub.Name, st.Nodes))
+	st.Message = strings.TrimSpace(fmt.Sprintf("已写入 %d 个节点到 %s。%s", st.Nodes, sub.File, st.Message))
+	return st, nil
+}
+
+// runSubscription 更新一个订阅并记录状态，同一订阅正在更新时直接返回当前状态
+func runSubscription(sub SubscriptionConfig, username string) SubscriptionStatus {
+	subscriptionMutex.Lock()
+	st, ok := subscriptionStatuses[sub.Name]
+	if !ok {
+		st = &SubscriptionStatus{}
+		subscriptionStatuses[sub.Name] = st
+	}
+	if st.Running {
+		result := *st
+		subscriptionMutex.Unlock()
+		return result
+	}
+	st.Running = true
+	subscriptionMutex.Unlock()
+
+	result, err := updateSubscription(sub, username)
+	now := time.Now()
+	result.LastFetch = &now
+	result.LastSuccess = st.LastSuccess
+	if err != nil {
+		result.Error = err.Error()
+		result.Failures = st.Failures + 1
+		log.Printf("订阅 '%s' 更新失败: %v", sub.Name, err)
+	} else {
+		result.LastSuccess = &now
+		log.Printf("订阅 '%s': %s", sub.Name, result.Message)
+	}
+
+	subscriptionMutex.Lock()
+	defer subscriptionMutex.Unlock()
+	*st = result
+	return result
+}
+
+// subscriptionDue 判断订阅是否到了更新时间。上一次更新失败时按 retryDelay 提前重试；
+// 编辑器重启后没有内存中的记录，以订阅文件的修改时间为准。
+func subscriptionDue(sub *SubscriptionConfig, now time.Time) bool {
+	if sub.Disabled {
+		return false
+	}
+	st := subscriptionStatus(sub.Name)
+	last := st.LastFetch
+	if last != nil && st.Failures > 0 {
+		return now.Sub(*last) >= sub.retryDelay(st.Failures)
+	}
+	if last == nil {
+		baseDir, err := activeConfigDir()
+		if err != nil {
+			return false
+		}
+		info, err := os.Stat(filepath.Join(baseDir, sub.File))
+		if err != nil {
+			return true
+		}
+		modTime := info.ModTime()
+		last = &modTime
+	}
+	return now.Sub(*last) >= sub.interval()
+}
+
+// runSubscriptionScheduler 后台每分钟检查一次，依次更新到期的订阅
+func runSubscriptionScheduler() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		if _, err := activeConfigDir(); err != nil {
+			continue
+		}
+		for _, sub := range getEditorConfig().Subscriptions {
+			if subscriptionDue(&sub, now) {
+				runSubscription(sub, "")
+			}
+		}
+	}
+}
+
+// SubscriptionInfo ...
+type SubscriptionInfo struct {
+	SubscriptionConfig
+	Status SubscriptionStatus `json:"status"`
+}
+
+// subscriptionsHandler 处理 /api/subscriptions 请求，列出所有订阅及其最近一次更新的状态
+func subscriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	list := []SubscriptionInfo{}
+	for _, sub := range getEditorConfig().Subscriptions {
+		list = append(list, SubscriptionInfo{SubscriptionConfig: sub, Status: subscriptionStatus(sub.Name)})
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(map[string]interface{}{"subscriptions": list})
+}
+
+// SaveSubscriptionRequest ...
+type SaveSubscriptionRequest struct {
+	Subscription SubscriptionConfig `json:"subscription"`
+	OriginalName string             `json:"original_name,omitempty"` // 修改已有订阅时为原名称，为空表示新增
+}
+
+// saveSubscriptionHandler 处理 /api/save_subscription 请求，新增或修改一个订阅并写入编辑器配置
+func saveSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	var req SaveSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "无效的请求体", http.StatusBadRequest)
+		return
+	}
+	sub := req.Subscription
+
+	editorConfigMutex.Lock()
+	defer editorConfigMutex.Unlock()
+
+	found := -1
+	var others []SubscriptionConfig
+	for i, existing := range editorConfig.Subscriptions {
+		if req.OriginalName != "" && existing.Name == req.OriginalName {
+			found = i
+			continue
+		}
+		others = append(others, existing)
+	}
+	if req.OriginalName != "" && found < 0 {
+		writeJSONError(w, fmt.Sprintf("订阅 '%s' 不存在", req.OriginalName), http.StatusNotFound)
+		return
+	}
+	if err := validateSubscription(&sub, others); err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	previous := editorConfig.Subscriptions
+	updated := append([]SubscriptionConfig(nil), previous...)
+	if found >= 0 {
+		updated[found] = sub
+	} else {
+		updated = append(updated, sub)
+	}
+	editorConfig.Subscriptions = updated
+	if err := saveEditorConfigLocked(); err != nil {
+		editorConfig.Subscriptions = previous
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if found >= 0 && req.OriginalName != sub.Name {
+		subscriptionMutex.Lock()
+		delete(subscriptionStatuses, req.OriginalName)
+		subscriptionMutex.Unlock()
+	}
+	log.Printf("用户 '%s' 保存了订阅 '%s'", sessionUser(r), sub.Name)
+	writeJSONResponse(w, "success", fmt.Sprintf("已保存订阅 '%s'。", sub.Name), http.StatusOK)
+}
+
+// SubscriptionNameRequest ...
+type SubscriptionNameRequest struct {
+	Name string `json:"name"`
+}
+
+// findSubscription 按名称查找订阅
+func findSubscription(name string) (SubscriptionConfig, bool) {
+	for _, sub := range getEditorConfig().Subscriptions {
+		if sub.Name == name {
+			return sub, true
+		}
+	}
+	return SubscriptionConfig{}, false
+}
+
+// deleteSubscriptionHandler 处理 /api/delete_subscription 请求：删除订阅设置，已生成的文件保留，需要时另行删除
+func deleteSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	var req SubscriptionNameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "无效的请求体", http.StatusBadRequest)
+		return
+	}
+
+	editorConfigMutex.Lock()
+	defer editorConfigMutex.Unlock()
+
+	previous := editorConfig.Subscriptions
+	var updated []SubscriptionConfig
+	var file string
+	for _, sub := range previous {
+		if sub.Name == req.Name {
+			file = sub.File
+			continue
+		}
+		updated = append(updated, sub)
+	}
+	if len(updated) == len(previous) {
+		writeJSONError(w, fmt.Sprintf("订阅 '%s' 不存在", req.Name), http.StatusNotFound)
+		return
+	}
+	editorConfig.Subscriptions = updated
+	if err := saveEditorConfigLocked(); err != nil {
+		editorConfig.Subscriptions = previous
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	subscriptionMutex.Lock()
+	delete(subscriptionStatuses, req.Name)
+	subscriptionMutex.Unlock()
+	log.Printf("用户 '%s' 删除了订阅 '%s'", sessionUser(r), req.Name)
+	writeJSONResponse(w, "success", fmt.Sprintf("已删除订阅 '%s'，生成的文件 %s 不会被删除。", req.Name, file), http.StatusOK)
+}
+
+// refreshSubscriptionHandler 处理 /api/refresh_subscription 请求，立即更新一个订阅并返回结果
+func refreshSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, "只支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	var req SubscriptionNameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "无效的请求体", http.StatusBadRequest)
+		return
+	}
+	sub, ok := findSubscription(req.Name)
+	if !ok {
+		writeJSONError(w, fmt.Sprintf("订阅 '%s' 不存在", req.Name), http.StatusNotFound)
+		return
+	}
+	if _, err := activeConfigDir(); err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	st := runSubscription(sub, sessionUser(r))
+	response := map[string]interface{}{"status": st}
+	statusCode := http.StatusOK
+	if st.Error != "" {
+		response["error"] = st.Error
+		statusCode = http.StatusBadGateway
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(response)
+}
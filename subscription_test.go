@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useTestConfigDir 把活动配置目录切换到写入了 files 的临时目录，测试结束后恢复
+func useTestConfigDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	currentConfigPathMutex.Lock()
+	previous := currentConfigPath
+	currentConfigPath = dir
+	currentConfigPathMutex.Unlock()
+	t.Cleanup(func() {
+		currentConfigPathMutex.Lock()
+		currentConfigPath = previous
+		currentConfigPathMutex.Unlock()
+	})
+	return dir
+}
+
+// useFakeSingbox 在 PATH 中放一个假的 sing-box：任何配置文件包含 BAD 时检查失败
+func useFakeSingbox(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\nif grep -rq BAD \"$3\"; then echo \"BAD found\"; exit 1; fi\n"
+	if err := os.WriteFile(filepath.Join(dir, "sing-box"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// subscriptionFixtures 同一组节点（HK 1、HK 2、US 1，以及可选的 HK BAD）的三种订阅格式
+func subscriptionFixtures(bad bool) map[string]string {
+	links := []string{
+		"vless://uuid-1@h1.example:443?security=tls#HK%201",
+		"trojan://pw@h2.example:443#HK%202",
+		"trojan://pw@h3.example:443#US%201",
+	}
+	outbounds := []string{
+		`{"type": "selector", "tag": "select", "outbounds": ["HK 1"]}`,
+		`{"type": "vless", "tag": "HK 1", "server": "h1.example", "server_port": 443, "uuid": "uuid-1", "tls": {"enabled": true}}`,
+		`{"type": "trojan", "tag": "HK 2", "server": "h2.example", "server_port": 443, "password": "pw"}`,
+		`{"type": "trojan", "tag": "US 1", "server": "h3.example", "server_port": 443, "password": "pw"}`,
+		`{"type": "direct", "tag": "direct"}`,
+	}
+	proxies := []string{
+		"  - {name: HK 1, type: vless, server: h1.example, port: 443, uuid: uuid-1, tls: true}",
+		"  - {name: HK 2, type: trojan, server: h2.example, port: 443, password: pw}",
+		"  - {name: US 1, type: trojan, server: h3.example, port: 443, password: pw}",
+	}
+	if bad {
+		links = append(links, "trojan://pw@h4.example:443#HK%20BAD")
+		outbounds = append(outbounds, `{"type": "trojan", "tag": "HK BAD", "server": "h4.example", "server_port": 443, "password": "pw"}`)
+		proxies = append(proxies, "  - {name: HK BAD, type: trojan, server: h4.example, port: 443, password: pw}")
+	}
+	return map[string]string{
+		"links":    base64.StdEncoding.EncodeToString([]byte("剩余流量：10G\n" + strings.Join(links, "\n"))),
+		"sing-box": `{"outbounds": [` + strings.Join(outbounds, ", ") + `]}`,
+		"clash":    "proxies:\n" + strings.Join(proxies, "\n") + "\n",
+	}
+}
+
+func TestUpdateSubscription(t *testing.T) {
+	useFakeSingbox(t)
+	for _, format := range []string{"links", "sing-box", "clash"} {
+		t.Run(format, func(t *testing.T) {
+			var mu sync.Mutex
+			body := subscriptionFixtures(false)[format]
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				w.Write([]byte(body))
+			}))
+			defer server.Close()
+
+			dir := useTestConfigDir(t, map[string]string{
+				"10_other.json": `{"outbounds": [{"type": "direct", "tag": "A-US 1"}]}`,
+			})
+			sub := SubscriptionConfig{
+				Name:      "sub",
+				URL:       server.URL,
+				TagPrefix: "A-",
+				Include:   "HK|US",
+				Exclude:   "2$",
+				File:      "60_sub.json",
+			}
+
+			st, err := updateSubscription(sub, "")
+			if err != nil {
+				t.Fatalf("updateSubscription: %v", err)
+			}
+			if st.Format != format || st.Nodes != 2 {
+				t.Fatalf("format = %q, nodes = %d, want %q, 2", st.Format, st.Nodes, format)
+			}
+			written, err := os.ReadFile(filepath.Join(dir, sub.File))
+			if err != nil {
+				t.Fatal(err)
+			}
+			outbounds := jsoncParse(written).Get("outbounds")
+			var tags []string
+			for _, outbound := range outbounds.Array() {
+				tags = append(tags, outbound.Get("tag").String())
+			}
+			// HK 2 被 exclude 去掉；A-US 1 与 10_other.json 冲突，自动加后缀
+			want := []string{"sub", "sub-auto", "A-HK 1", "A-US 1-2"}
+			if strings.Join(tags, ",") != strings.Join(want, ",") {
+				t.Errorf("tags = %q, want %q", tags, want)
+			}
+			var members []string
+			for _, member := range outbounds.Get("0.outbounds").Array() {
+				members = append(members, member.String())
+			}
+			if got := strings.Join(members, ","); got != "sub-auto,A-HK 1,A-US 1-2" {
+				t.Errorf("selector outbounds = %q", got)
+			}
+
+			// 内容相同时不重写文件
+			st, err = updateSubscription(sub, "")
+			if err != nil {
+				t.Fatalf("second updateSubscription: %v", err)
+			}
+			if !strings.Contains(st.Message, "没有变化") {
+				t.Errorf("message = %q, want no change", st.Message)
+			}
+
+			// 检查失败时文件保持不变
+			mu.Lock()
+			body = subscriptionFixtures(true)[format]
+			mu.Unlock()
+			sub.Exclude = ""
+			st, err = updateSubscription(sub, "")
+			if err == nil {
+				t.Fatal("updateSubscription succeeded, want check failure")
+			}
+			if !strings.Contains(st.CheckOutput, "BAD found") {
+				t.Errorf("check output = %q", st.CheckOutput)
+			}
+			after, _ := os.ReadFile(filepath.Join(dir, sub.File))
+			if string(after) != string(written) {
+				t.Errorf("subscription file changed after failed check:\n%s", after)
+			}
+		})
+	}
+}
+
+func TestUpdateSubscriptionErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/text":
+			w.Write([]byte("hello"))
+		default:
+			w.Write([]byte(subscriptionFixtures(false)["links"]))
+		}
+	}))
+	defer server.Close()
+	useTestConfigDir(t, map[string]string{
+		"10_other.json": `{"outbounds": [{"type": "direct", "tag": "sub"}]}`,
+	})
+
+	tests := []struct {
+		name string
+		sub  SubscriptionConfig
+		want string
+	}{
+		{"http error", SubscriptionConfig{Name: "x", URL: server.URL + "/missing", File: "60_x.json"}, "404"},
+		{"unknown content", SubscriptionConfig{Name: "x", URL: server.URL + "/text", File: "60_x.json"}, "无法识别"},
+		{"nothing left", SubscriptionConfig{Name: "x", URL: server.URL, Include: "JP", File: "60_x.json"}, "没有可用的节点"},
+		{"group tag taken", SubscriptionConfig{Name: "sub", URL: server.URL, File: "60_x.json"}, "已被其他文件"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := updateSubscription(tt.sub, "")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriptionRetry(t *testing.T) {
+	sub := SubscriptionConfig{Name: "retry", Interval: "1h", File: "60_retry.json"}
+	for failures, want := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 4: 8 * time.Minute, 10: time.Hour, 100: time.Hour} {
+		if got := sub.retryDelay(failures); got != want {
+			t.Errorf("retryDelay(%d) = %v, want %v", failures, got, want)
+		}
+	}
+
+	last := time.Now()
+	subscriptionMutex.Lock()
+	subscriptionStatuses[sub.Name] = &SubscriptionStatus{LastFetch: &last, Failures: 2, Error: "down"}
+	subscriptionMutex.Unlock()
+	t.Cleanup(func() {
+		subscriptionMutex.Lock()
+		delete(subscriptionStatuses, sub.Name)
+		subscriptionMutex.Unlock()
+	})
+	if subscriptionDue(&sub, last.Add(time.Minute)) {
+		t.Error("due one minute after the second failure")
+	}
+	if !subscriptionDue(&sub, last.Add(2*time.Minute)) {
+		t.Error("not due two minutes after the second failure")
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+// 标签种类。sing-box 中端点与出站共用同一个标签空间，因此端点也按出站处理。
+const (
+	tagKindOutbound  = "outbound"
+	tagKindInbound   = "inbound"
+	tagKindDNSServer = "dns_server"
+	tagKindRuleSet   = "rule_set"
+)
+
+// tagKindName 返回标签种类的中文名
+func tagKindName(kind string) string {
+	switch kind {
+	case tagKindOutbound:
+		return "出站"
+	case tagKindInbound:
+		return "入站"
+	case tagKindDNSServer:
+		return "DNS 服务器"
+	case tagKindRuleSet:
+		return "规则集"
+	}
+	return kind
+}
+
+// TagOccurrence 标签在某个文件中的一次定义或引用
+type TagOccurrence struct {
+	Kind       string `json:"kind"`
+	Tag        string `json:"tag"`
+	File       string `json:"file"`
+	Path       string `json:"path"`
+	Line       int    `json:"line"`
+	Column     int    `json:"column"`
+	Definition bool   `json:"definition,omitempty"`
+	Offset     int    `json:"-"` // 字符串值（含引号）在文件中的字节偏移
+	Raw        string `json:"-"` // 字符串值在文件中的原文（含引号）
+}
+
+// tagCollector 遍历一个文件，收集其中所有标签的定义和引用
+type tagCollector struct {
+	file        string
+	content     []byte
+	occurrences []TagOccurrence
+}
+
+func (c *tagCollector) add(kind string, value gjson.Result, path string, definition bool) {
+	if value.Type != gjson.String || value.Str == "" {
+		return
+	}
+	line, column := offsetPosition(c.content, value.Index)
+	c.occurrences = append(c.occurrences, TagOccurrence{
+		Kind:       kind,
+		Tag:        value.Str,
+		File:       c.file,
+		Path:       path,
+		Line:       line,
+		Column:     column,
+		Definition: definition,
+		Offset:     value.Index,
+		Raw:        value.Raw,
+	})
+}
+
+// ref 记录引用，值可以是单个字符串或字符串数组
+func (c *tagCollector) ref(kind string, parent gjson.Result, parentPath, key string) {
+	c.refExcept(kind, parent, parentPath, key, "")
+}
+
+// refExcept 同 ref，但跳过取值为保留字 reserved 的项（它不是标签）
+func (c *tagCollector) refExcept(kind string, parent gjson.Result, parentPath, key, reserved string) {
+	value := parent.Get(key)
+	path := joinJSONPath(parentPath, key)
+	if value.IsArray() {
+		value.ForEach(func(k, item gjson.Result) bool {
+			if reserved == "" || item.Str != reserved {
+				c.add(kind, item, joinJSONPath(path, strconv.Itoa(int(k.Num))), false)
+			}
+			return true
+		})
+		return
+	}
+	if reserved == "" || value.Str != reserved {
+		c.add(kind, value, path, false)
+	}
+}
+
+func (c *tagCollector) def(kind string, parent gjson.Result, parentPath string) {
+	c.add(kind, parent.Get("tag"), joinJSONPath(parentPath, "tag"), true)
+}
+
+// forEachItem 遍历数组，回调中给出元素和它的路径
+func forEachItem(list gjson.Result, path string, fn func(item gjson.Result, itemPath string)) {
+	if !list.IsArray() {
+		return
+	}
+	list.ForEach(func(key, item gjson.Result) bool {
+		fn(item, joinJSONPath(path, strconv.Itoa(int(key.Num))))
+		return true
+	})
+}
+
+// dialRefs 收集拨号字段中的引用：detour 指向出站，domain_resolver 指向 DNS 服务器
+func (c *tagCollector) dialRefs(obj gjson.Result, path string) {
+	c.ref(tagKindOutbound, obj, path, "detour")
+	c.domainResolver(obj, path, "domain_resolver")
+}
+
+// domainResolver 既可以是服务器标签，也可以是 {"server": "..."} 对象
+func (c *tagCollector) domainResolver(obj gjson.Result, path, key string) {
+	if resolver := obj.Get(key); resolver.IsObject() {
+		c.ref(tagKindDNSServer, resolver, joinJSONPath(path, key), "server")
+	} else {
+		c.ref(tagKindDNSServer, obj, path, key)
+	}
+}
+
+// routeRule 收集路由规则（包括逻辑规则的子规则）中的引用
+func (c *tagCollector) routeRule(rule gjson.Result, path string) {
+	c.ref(tagKindInbound, rule, path, "inbound")
+	c.ref(tagKindOutbound, rule, path, "outbound")
+	c.ref(tagKindRuleSet, rule, path, "rule_set")
+	c.ref(tagKindDNSServer, rule, path, "server")
+	forEachItem(rule.Get("rules"), joinJSONPath(path, "rules"), c.routeRule)
+}
+
+// dnsRule 收集 DNS 规则（包括逻辑规则的子规则）中的引用
+func (c *tagCollector) dnsRule(rule gjson.Result, path string) {
+	c.ref(tagKindInbound, rule, path, "inbound")
+	// DNS 规则中 "outbound": "any" 匹配任意出站发起的查询
+	c.refExcept(tagKindOutbound, rule, path, "outbound", "any")
+	c.ref(tagKindRuleSet, rule, path, "rule_set")
+	c.ref(tagKindDNSServer, rule, path, "server")
+	forEachItem(rule.Get("rules"), joinJSONPath(path, "rules"), c.dnsRule)
+}
+
+// collectTagOccurrences 收集一个配置文件中所有标签的定义和引用
+func collectTagOccurrences(file string, content []byte) []TagOccurrence {
+	c := &tagCollector{file: file, content: content}
+	root := jsoncParse(content)
+
+	for _, section := range []string{"outbounds", "endpoints"} {
+		forEachItem(root.Get(section), section, func(ob gjson.Result, path string) {
+			c.def(tagKindOutbound, ob, path)
+			c.dialRefs(ob, path)
+			if kind := ob.Get("type").String(); kind == "selector" || kind == "urltest" {
+				c.ref(tagKindOutbound, ob, path, "outbounds")
+				c.ref(tagKindOutbound, ob, path, "default")
+			}
+		})
+	}
+	forEachItem(root.Get("inbounds"), "inbounds", func(in gjson.Result, path string) {
+		c.def(tagKindInbound, in, path)
+		c.ref(tagKindInbound, in, path, "detour")
+		c.ref(tagKindRuleSet, in, path, "route_address_set")
+		c.ref(tagKindRuleSet, in, path, "route_exclude_address_set")
+	})
+
+	dns := root.Get("dns")
+	forEachItem(dns.Get("servers"), "dns.servers", func(server gjson.Result, path string) {
+		c.def(tagKindDNSServer, server, path)
+		c.dialRefs(server, path)
+		c.ref(tagKindDNSServer, server, path, "address_resolver")
+	})
+	forEachItem(dns.Get("rules"), "dns.rules", c.dnsRule)
+	c.ref(tagKindDNSServer, dns, "dns", "final")
+
+	route := root.Get("route")
+	forEachItem(route.Get("rules"), "route.rules", c.routeRule)
+	forEachItem(route.Get("rule_set"), "route.rule_set", func(set gjson.Result, path string) {
+		c.def(tagKindRuleSet, set, path)
+		c.ref(tagKindOutbound, set, path, "download_detour")
+	})
+	c.ref(tagKindOutbound, route, "route", "final")
+	c.domainResolver(route, "route", "default_domain_resolver")
+
+	c.dialRefs(root.Get("ntp"), "ntp")
+	c.ref(tagKindOutbound, root.Get("experimental.clash_api"), "experimental.clash_api", "external_ui_download_detour")
+	return c.occurrences
+}
+
+// TagIndex 整个配置目录的标签索引
+type TagIndex struct {
+	Occurrences []TagOccurrence
+	Errors      []string // 无法解析的文件
+	// 合并后的第一个出站：route.final 未设置时它就是默认出站
+	FirstOutbound string
+	HasFinal      bool
+}
+
+// buildTagIndex 按 sing-box 的合并顺序读取活动目录中的所有配置文件并建立标签索引
+func buildTagIndex() (*TagIndex, error) {
+	baseDir, err := activeConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := listConfigFiles()
+	if err != nil {
+		return nil, err
+	}
+	index := &TagIndex{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(filepath.Join(baseDir, file))
+		if err != nil {
+			index.Errors = append(index.Errors, fmt.Sprintf("%s: %v", file, err))
+			continue
+		}
+		if err := validateJSONC(content); err != nil {
+			index.Errors = append(index.Errors, fmt.Sprintf("%s: %v", file, err))
+			continue
+		}
+		root := jsoncParse(content)
+		if index.FirstOutbound == "" {
+			index.FirstOutbound = root.Get("outbounds.0.tag").String()
+		}
+		if root.Get("route.final").String() != "" {
+			index.HasFinal = true
+		}
+		index.Occurrences = append(index.Occurrences, collectTagOccurrences(file, content)...)
+	}
+	return index, nil
+}
+
+// definitions 返回某种标签的所有定义：标签 -> 定义位置
+func (index *TagIndex) definitions(kind string) map[string][]TagOccurrence {
+	defs := make(map[string][]TagOccurrence)
+	for _, occ := range index.Occurrences {
+		if occ.Definition && occ.Kind == kind {
+			defs[occ.Tag] = append(defs[occ.Tag], occ)
+		}
+	}
+	return defs
+}
+
+// DuplicateTag 在多个位置定义的同一个标签
+type DuplicateTag struct {
+	Kind        string          `json:"kind"`
+	Tag         string          `json:"tag"`
+	Definitions []TagOccurrence `json:"definitions"`
+}
+
+// TagCheckResponse ...
+type TagCheckResponse struct {
+	Dangling   []TagOccurrence     `json:"dangling"`   // 引用了不存在的标签
+	Duplicates []DuplicateTag      `json:"duplicates"` // 重复定义的标签
+	Unused     []TagOccurrence     `json:"unused"`     // 没有被任何地方引用的出站
+	Tags       map[string][]string `json:"tags"`       // 每种标签的所有定义
+	Errors     []string            `json:"errors,omitempty"`
+}
+
+// checkTagReferences 找出悬空引用、重复定义和未使用的出站
+func checkTagReferences(index *TagIndex) TagCheckResponse {
+	resp := TagCheckResponse{
+		Dangling:   []TagOccurrence{},
+		Duplicates: []DuplicateTag{},
+		Unused:     []TagOccurrence{},
+		Tags:       make(map[string][]string),
+		Errors:     index.Errors,
+	}
+	defs := make(map[string]map[string][]TagOccurrence)
+	for _, kind := range []string{tagKindOutbound, tagKindInbound, tagKindDNSServer, tagKindRuleSet} {
+		defs[kind] = index.definitions(kind)
+		tags := []string{}
+		for tag, locations := range defs[kind] {
+			tags = append(tags, tag)
+			if len(locations) > 1 {
+				resp.Duplicates = append(resp.Duplicates, DuplicateTag{Kind: kind, Tag: tag, Definitions: locations})
+			}
+		}
+		sort.Strings(tags)
+		resp.Tags[kind] = tags
+	}
+	sort.Slice(resp.Duplicates, func(i, j int) bool {
+		if resp.Duplicates[i].Kind != resp.Duplicates[j].Kind {
+			return resp.Duplicates[i].Kind < resp.Duplicates[j].Kind
+		}
+		return resp.Duplicates[i].Tag < resp.Duplicates[j].Tag
+	})
+
+	referenced := make(map[string]bool)
+	for _, occ := range index.Occurrences {
+		if occ.Definition {
+			continue
+		}
+		if _, ok := defs[occ.Kind][occ.Tag]; !ok {
+			resp.Dangling = append(resp.Dangling, occ)
+		}
+		if occ.Kind == tagKindOutbound {
+			referenced[occ.Tag] = true
+		}
+	}
+	for _, occ := range index.Occurrences {
+		if !occ.Definition || occ.Kind != tagKindOutbound || referenced[occ.Tag] {
+			continue
+		}
+		// 未设置 route.final 时，第一个出站是默认出站
+		if !index.HasFinal && occ.Tag == index.FirstOutbound {
+			continue
+		}
+		resp.Unused = append(resp.Unused, occ)
+	}
+	return resp
+}
+
+// checkTagsHandler 处理 /api/check_tags 请求，检查整个配置目录中的标签引用
+func checkTagsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	index, err := buildTagIndex()
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(checkTagReferences(index))
+}
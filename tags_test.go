@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCollectTagOccurrencesDNSOutboundAny(t *testing.T) {
+	content := []byte(`{
+  "dns": {
+    "rules": [
+      {"outbound": "any", "server": "local"},
+      {"outbound": ["any", "proxy"], "server": "remote"}
+    ]
+  },
+  "route": {"rules": [{"outbound": "any"}]}
+}`)
+	var refs []string
+	for _, occ := range collectTagOccurrences("dns.json", content) {
+		if occ.Kind == tagKindOutbound {
+			refs = append(refs, occ.Path+"="+occ.Tag)
+		}
+	}
+	// 只有 DNS 规则中的 any 是保留值，路由规则中的 any 仍是（不存在的）出站标签
+	want := "dns.rules.1.outbound.1=proxy,route.rules.0.outbound=any"
+	if got := strings.Join(refs, ","); got != want {
+		t.Errorf("outbound refs = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+)
+
+// treeValuePreviewLength 标量值预览的最大字符数
+const treeValuePreviewLength = 60
+
+// valueType 返回值的类型名：object / array / string / number / boolean / null
+func valueType(value gjson.Result) string {
+	switch {
+	case value.IsObject():
+		return "object"
+	case value.IsArray():
+		return "array"
+	case value.Type == gjson.String:
+		return "string"
+	case value.Type == gjson.Number:
+		return "number"
+	case value.Type == gjson.True, value.Type == gjson.False:
+		return "boolean"
+	}
+	return "null"
+}
+
+// valueLength 返回对象的键数或数组的元素个数，标量返回 0
+func valueLength(value gjson.Result) int {
+	if !value.IsObject() && !value.IsArray() {
+		return 0
+	}
+	n := 0
+	value.ForEach(func(_, _ gjson.Result) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// TreeNode 配置树中的一个节点
+type TreeNode struct {
+	Key    string `json:"key"`              // 键名或数组下标
+	Label  string `json:"label"`            // 显示名称：键名，带标签数组的元素为标签，规则为摘要
+	Path   string `json:"path"`             // 读取和保存片段使用的路径，可直接传给 get_content / save_content
+	Type   string `json:"type"`             // object / array / string / number / boolean / null
+	Length int    `json:"length,omitempty"` // 对象的键数或数组的元素个数
+	Value  string `json:"value,omitempty"`  // 标量值的原文，过长时截断
+	Line   int    `json:"line"`             // 在文件中的行号
+}
+
+// TreeResponse ...
+type TreeResponse struct {
+	Path     string     `json:"path"`
+	Type     string     `json:"type"`
+	Children []TreeNode `json:"children"`
+}
+
+// treeChildren 列出 value 的直接子节点，parentPath 是用户使用的路径（可以包含标签）
+func treeChildren(content []byte, value gjson.Result, parentPath string) []TreeNode {
+	children := []TreeNode{}
+	var labels map[int]TopKeyItem
+	if value.IsArray() && (containsString(taggedArrays, parentPath) || containsString(ruleArrays, parentPath)) {
+		labels = make(map[int]TopKeyItem)
+		for _, item := range elementItems(value, parentPath, 0) {
+			labels[item.Index] = item
+		}
+	}
+	value.ForEach(func(key, child gjson.Result) bool {
+		node := TreeNode{
+			Key:    key.Str,
+			Label:  key.Str,
+			Path:   joinJSONPath(parentPath, key.Str),
+			Type:   valueType(child),
+			Length: valueLength(child),
+		}
+		if value.IsArray() {
+			index := int(key.Int())
+			node.Key = strconv.Itoa(index)
+			node.Label = node.Key
+			node.Path = joinJSONPath(parentPath, node.Key)
+			if item, ok := labels[index]; ok {
+				node.Label, node.Path = item.Label, item.Path
+			}
+		}
+		if node.Type != "object" && node.Type != "array" {
+			node.Value = child.Raw
+			if utf8.RuneCountInString(node.Value) > treeValuePreviewLength {
+				node.Value = string([]rune(node.Value)[:treeValuePreviewLength]) + "…"
+			}
+		}
+		node.Line, _ = offsetPosition(content, child.Index)
+		children = append(children, node)
+		return true
+	})
+	return children
+}
+
+// getTreeHandler 处理 /api/get_tree 请求：按需返回 path 处（为空时为文件根）的直接子节点及其类型、长度，
+// 编辑器展开某个节点时再请求它的子节点。path 支持与 get_content 相同的标签写法。
+func getTreeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "只支持 GET 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	filename := r.URL.Query().Get("filename")
+	userPath := r.URL.Query().Get("path")
+	filePath, err := validateFilename(filename)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	contentBytes, _, err := readFileWithETag(filePath)
+	if err != nil {
+		writeJSONError(w, fmt.Sprintf("无法读取文件 '%s': %v", filename, err), http.StatusInternalServerError)
+		return
+	}
+	if err := validateConfigFile(contentBytes); err != nil {
+		writeSyntaxError(w, "文件", err.(*JSONSyntaxError))
+		return
+	}
+	value := jsoncParse(contentBytes)
+	if userPath != "" {
+		realPath := resolvePath(contentBytes, userPath)
+		value = jsoncGet(contentBytes, realPath)
+		if !value.Exists() {
+			writeJSONError(w, fmt.Sprintf("路径 '%s' (解析为 '%s') 不存在。", userPath, realPath), http.StatusNotFound)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(TreeResponse{
+		Path:     userPath,
+		Type:     valueType(value),
+		Children: treeChildren(contentBytes, value, userPath),
+	})
+}
@@ -2,11 +2,15 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -14,7 +18,8 @@ import (
 type ConfigTypeInfo struct {
 	FunctionName string
 	FileName     string
-	Order        int // 用于排序的额外字段
+	Order        int    // 用于排序的额外字段
+	Dir          string // 文件所在的子目录（相对配置目录，根目录为空）
 }
 
 // configTypeMap 定义根键到功能名的映射及其显示顺序。
@@ -52,8 +57,33 @@ func isValidConfigDir(path string) bool {
 	return true
 }
 
+// checkRelativeConfigPath 校验相对配置目录的文件路径：只能用 / 分隔，
+// 不能是绝对路径，不能包含空段、. 或 ..，中间的目录不能是隐藏目录（如 .sb_editor、.git）。
+func checkRelativeConfigPath(filename string) error {
+	if filename == "" {
+		return fmt.Errorf("文件名为空")
+	}
+	if !strings.HasSuffix(filename, ".json") {
+		return fmt.Errorf("非法文件类型，只允许 .json 文件")
+	}
+	if strings.Contains(filename, `\`) || strings.HasPrefix(filename, "/") || filepath.IsAbs(filename) {
+		return fmt.Errorf("文件名必须是相对配置目录的路径，并以 / 分隔")
+	}
+	parts := strings.Split(filename, "/")
+	for i, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("禁止访问配置目录之外的文件")
+		}
+		if i < len(parts)-1 && strings.HasPrefix(part, ".") {
+			return fmt.Errorf("不允许访问隐藏目录 '%s'", part)
+		}
+	}
+	return nil
+}
+
 // validateFilename 辅助函数，用于安全验证文件名。
-// 确保文件存在于 currentConfigPath 且是 .json 文件，防止路径遍历。
+// filename 是相对 currentConfigPath 的路径（可以位于子目录中，如 regions/hk.json），
+// 确保文件存在且是 .json 文件，防止路径遍历和指向目录之外的符号链接。
 func validateFilename(filename string) (string, error) {
 	currentConfigPathMutex.RLock() // 读取锁
 	baseDir := currentConfigPath
@@ -62,41 +92,143 @@ func validateFilename(filename string) (string, error) {
 	if baseDir == "" {
 		return "", fmt.Errorf("未设置配置目录，请先选择一个目录。")
 	}
-
-	if filename == "" {
-		return "", fmt.Errorf("文件名为空")
-	}
-	if !strings.HasSuffix(filename, ".json") {
-		return "", fmt.Errorf("非法文件类型，只允许 .json 文件")
+	if err := checkRelativeConfigPath(filename); err != nil {
+		return "", err
 	}
 
-	fullPath := filepath.Join(baseDir, filename)
+	fullPath := filepath.Join(baseDir, filepath.FromSlash(filename))
 	cleanPath := filepath.Clean(fullPath)
 
 	// 再次验证清理后的路径是否仍然在 baseDir 内部
-	if !strings.HasPrefix(cleanPath, filepath.Clean(baseDir)) {
+	if !isWithinDir(baseDir, cleanPath) {
 		return "", fmt.Errorf("禁止访问配置目录之外的文件")
 	}
 
-	// 检查文件名是否在 baseDir 的根目录下实际存在
-	entries, err := os.ReadDir(baseDir)
-	if err != nil {
-		return "", fmt.Errorf("无法验证文件名：读取配置目录失败：%v", err)
+	// 检查文件是否实际存在且不是目录
+	info, err := os.Stat(cleanPath)
+	if err != nil || info.IsDir() {
+		return "", fmt.Errorf("文件未找到或不在配置目录中")
 	}
 
-	isValidFile := false
-	for _, entry := range entries {
-		if !entry.IsDir() && entry.Name() == filename {
-			isValidFile = true
+	// 拒绝指向配置目录之外的符号链接（包括路径中间的目录）
+	if _, err := resolveInsideConfigDir(cleanPath); err != nil {
+		return "", err
+	}
+
+	return cleanPath, nil
+}
+
+// errFileExists 新建或重命名的目标文件已经存在
+var errFileExists = errors.New("目标文件已存在")
+
+// validateNewFilename 校验要新建（或重命名为）的文件名，规则与 validateFilename 相同，要求文件尚不存在。
+// 文件名（最后一段）不能以 . 开头，编辑器自身的数据和临时文件都以 . 开头。
+// 不存在的上级目录由调用方通过 ensureParentDir 创建。
+func validateNewFilename(filename string) (string, error) {
+	baseDir, err := activeConfigDir()
+	if err != nil {
+		return "", err
+	}
+	if err := checkRelativeConfigPath(filename); err != nil {
+		return "", err
+	}
+	if name := path.Base(filename); name == ".json" || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("文件名不能为空，也不能以 . 开头")
+	}
+	cleanPath := filepath.Clean(filepath.Join(baseDir, filepath.FromSlash(filename)))
+	if !isWithinDir(baseDir, cleanPath) {
+		return "", fmt.Errorf("禁止访问配置目录之外的文件")
+	}
+	if _, err := os.Lstat(cleanPath); err == nil {
+		return "", errFileExists
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("无法检查文件 '%s': %v", filename, err)
+	}
+	// 最近一个已存在的上级目录不能是指向配置目录之外的符号链接
+	dir := filepath.Dir(cleanPath)
+	for isWithinDir(baseDir, dir) && dir != filepath.Clean(baseDir) {
+		if _, err := os.Lstat(dir); err == nil {
 			break
 		}
+		dir = filepath.Dir(dir)
 	}
+	if _, err := resolveInsideConfigDir(dir); err != nil {
+		return "", err
+	}
+	return cleanPath, nil
+}
 
-	if !isValidFile {
-		return "", fmt.Errorf("文件未找到或不允许在根配置目录中")
+// ensureParentDir 创建文件所在的（子）目录
+func ensureParentDir(filePath string) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("无法创建目录: %v", err)
 	}
+	return nil
+}
 
-	return cleanPath, nil
+// listConfigFiles 返回活动配置目录根目录中 sing-box 会读取的 .json 文件，按文件名排序（即 sing-box 的合并顺序）。
+// sing-box -C 不会递归读取子目录，因此合并视图、标签检查等只使用根目录中的文件。
+func listConfigFiles() ([]string, error) {
+	baseDir, err := activeConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取配置目录 '%s': %v", baseDir, err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// listConfigTree 递归列出活动配置目录（包括子目录）中的所有 .json 文件，返回以 / 分隔的相对路径。
+// 隐藏目录（.sb_editor、.git 等）被跳过，指向目录的符号链接不会被跟随。
+func listConfigTree() ([]string, error) {
+	baseDir, err := activeConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	// WalkDir 不跟随符号链接，配置目录本身是符号链接时先解析出真实目录
+	root, err := filepath.EvalSymlinks(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取配置目录 '%s': %v", baseDir, err)
+	}
+	var files []string
+	err = filepath.WalkDir(root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err
+			}
+			log.Printf("无法读取 '%s': %v", p, err)
+			return nil
+		}
+		if entry.IsDir() {
+			if p != root && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("无法读取配置目录 '%s': %v", baseDir, err)
+	}
+	sort.Strings(files)
+	return files, nil
 }
 
 // writeJSONResponse 辅助函数，用于向客户端返回JSON格式的成功响应。
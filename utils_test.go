@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListConfigTreeSymlinkRoot(t *testing.T) {
+	real := t.TempDir()
+	for _, name := range []string{"00_log.json", "sub/10_out.json", ".sb_editor/backups/x.json", "notes.txt"} {
+		path := filepath.Join(real, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(`{}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(t.TempDir(), "config")
+	if err := os.Symlink(real, link); err != nil {
+		t.Skip(err)
+	}
+	useTestConfigDir(t, nil)
+	currentConfigPathMutex.Lock()
+	currentConfigPath = link
+	currentConfigPathMutex.Unlock()
+
+	files, err := listConfigTree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(files, ","), "00_log.json,sub/10_out.json"; got != want {
+		t.Errorf("listConfigTree() = %q, want %q", got, want)
+	}
+}